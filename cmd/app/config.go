@@ -32,6 +32,16 @@ var conf struct {
 	LogLevel  string `env:"LOG_LEVEL" envDefault:"debug"`
 	LogFormat string `env:"LOG_FORMAT" envDefault:"logstash"`
 	Dry       bool   `env:"DRY" envDefault:"false"`
+
+	SchedulerInterval time.Duration `env:"SCHEDULER_INTERVAL" envDefault:"5s"`
+
+	WebhookUrls   []string `env:"WEBHOOK_URLS" envSeparator:","`
+	WebhookSecret string   `env:"WEBHOOK_SECRET"`
+
+	TrackerSecret string `env:"TRACKER_SECRET"`
+
+	ApiAddr   string `env:"API_ADDR"`
+	WebAppUrl string `env:"WEBAPP_URL"`
 }
 
 func InitConfig() {
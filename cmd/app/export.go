@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gotestbot/internal/dao"
+	"gotestbot/internal/export"
+	"gotestbot/internal/service"
+	"os"
+)
+
+// runExport is the export subcommand, it writes the room results to a file without starting the bot:
+//
+//	app export -room <roomId> [-format xlsx|csv|md] [-out <file>]
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	roomId := flags.String("room", "", "room id")
+	formatName := flags.String("format", "xlsx", "file format: xlsx, csv or md")
+	out := flags.String("out", "", "output file, named after the room by default, - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *roomId == "" {
+		fmt.Fprintln(os.Stderr, "export: -room is required")
+		flags.Usage()
+		return 2
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 2
+	}
+
+	pgRepository := dao.NewRepository(PgConnInit())
+	exporter := export.NewExporter(
+		service.NewRoomService(pgRepository, nil),
+		service.NewTaskService(pgRepository, nil),
+		service.NewRateService(pgRepository, nil))
+
+	fileName, data, err := exporter.Export(*roomId, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: unable to export room %v, %v\n", *roomId, err)
+		return 1
+	}
+	switch *out {
+	case "-":
+		_, err = os.Stdout.Write(data)
+	case "":
+		err = os.WriteFile(fileName, data, 0644)
+	default:
+		fileName = *out
+		err = os.WriteFile(fileName, data, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	if *out != "-" {
+		fmt.Fprintf(os.Stderr, "export: room %v written to %v\n", *roomId, fileName)
+	}
+	return 0
+}
@@ -2,15 +2,14 @@ package main
 
 import (
 	"github.com/go-pkgz/lgr"
-	"gotestbot/internal/bot/bot_handler"
-	"gotestbot/internal/bot/view"
 	"gotestbot/internal/dao"
-	"gotestbot/internal/service"
 	"gotestbot/sdk/tgbot"
 	"net/http"
 	"os"
 )
 
+// Handler is the webhook entry point of the bot. Telegram sends chat_member updates only when they are listed
+// in allowed_updates, so after deploying run "app set-webhook -url <url of Handler>" once.
 func Handler(rw http.ResponseWriter, req *http.Request) {
 
 	InitConfig()
@@ -30,20 +29,73 @@ func Handler(rw http.ResponseWriter, req *http.Request) {
 		lgr.Fatalf("[ERROR] unable to start app")
 	}
 
-	rateService := service.NewRateService(pgRepository)
-	taskService := service.NewTaskService(pgRepository)
-	viewSender := view.NewView(pgRepository, pgRepository, pgRepository, taskService, rateService, bot)
-	application := bot_handler.NewBotApp(viewSender,
-		service.NewRoomService(pgRepository),
-		service.NewTaskService(pgRepository),
-		rateService)
+	app := NewApplication(pgRepository, bot)
 	update, err := bot.WrapRequest(req)
 	if err != nil {
 		lgr.Printf("[ERROR] unable read request %v", err)
 		return
 	}
 
-	application.Handle(update)
+	app.Bot.Handle(update)
 
 	rw.WriteHeader(200)
 }
+
+// Tick runs due scheduler jobs. In webhook mode nothing runs in the background, so it has to be called periodically, e.g. by cron.
+func Tick(rw http.ResponseWriter, req *http.Request) {
+
+	InitConfig()
+	InitLogger()
+
+	pgDb := PgConnInit()
+	pgRepository := dao.NewRepository(pgDb)
+
+	bot, err := tgbot.NewBot(conf.TgToken, pgRepository)
+	if err != nil {
+		lgr.Fatalf("[ERROR] unable to start app")
+	}
+
+	processed, err := NewApplication(pgRepository, bot).Scheduler.Tick()
+	if err != nil {
+		lgr.Printf("[ERROR] scheduler tick failed %v", err)
+		rw.WriteHeader(500)
+		return
+	}
+	lgr.Printf("[DEBUG] scheduler tick processed %d jobs", processed)
+
+	rw.WriteHeader(200)
+}
+
+// Api serves the HTTP JSON API, see internal/api/openapi.yaml.
+func Api(rw http.ResponseWriter, req *http.Request) {
+
+	InitConfig()
+	InitLogger()
+
+	pgDb := PgConnInit()
+	pgRepository := dao.NewRepository(pgDb)
+
+	bot, err := tgbot.NewBot(conf.TgToken, pgRepository)
+	if err != nil {
+		lgr.Fatalf("[ERROR] unable to start app")
+	}
+
+	NewApplication(pgRepository, bot).Api.ServeHTTP(rw, req)
+}
+
+// WebApp serves the backend of the Web App voting board.
+func WebApp(rw http.ResponseWriter, req *http.Request) {
+
+	InitConfig()
+	InitLogger()
+
+	pgDb := PgConnInit()
+	pgRepository := dao.NewRepository(pgDb)
+
+	bot, err := tgbot.NewBot(conf.TgToken, pgRepository)
+	if err != nil {
+		lgr.Fatalf("[ERROR] unable to start app")
+	}
+
+	NewApplication(pgRepository, bot).WebApp.ServeHTTP(rw, req)
+}
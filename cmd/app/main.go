@@ -8,13 +8,21 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres" //for db migration
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
+	"gotestbot/internal/api"
 	"gotestbot/internal/bot/bot_handler"
 	"gotestbot/internal/bot/view"
 	"gotestbot/internal/dao"
+	"gotestbot/internal/export"
+	"gotestbot/internal/scheduler"
 	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"gotestbot/internal/webapp"
+	"gotestbot/internal/webhook"
 	"gotestbot/sdk/tgbot"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 )
 
@@ -29,6 +37,13 @@ func main() {
 
 	InitLogger()
 
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(runExport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "set-webhook" {
+		os.Exit(runSetWebhook(os.Args[2:]))
+	}
+
 	pgDb := PgConnInit()
 	pgRepository := dao.NewRepository(pgDb)
 
@@ -37,16 +52,28 @@ func main() {
 		lgr.Fatalf("[ERROR] unable to start app")
 	}
 
-	rateService := service.NewRateService(pgRepository)
-	taskService := service.NewTaskService(pgRepository)
-	viewSender := view.NewView(pgRepository, pgRepository, pgRepository, taskService, rateService, bot)
-	application := bot_handler.NewBotApp(viewSender,
-		service.NewRoomService(pgRepository),
-		service.NewTaskService(pgRepository),
-		rateService)
+	app := NewApplication(pgRepository, bot)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	app.Scheduler.Start(conf.SchedulerInterval, stop)
+
+	// live events reach the Web App only through the relay of a process serving the web app backend
+	if conf.ApiAddr == "" && conf.WebAppUrl != "" {
+		lgr.Printf("[WARN] WEBAPP_URL is set without API_ADDR, the web app backend and its live events are not served by this process")
+	}
+	if conf.ApiAddr != "" {
+		go app.Relay.Listen(stop)
+		go func() {
+			lgr.Printf("[INFO] api and web app backend listen on %v", conf.ApiAddr)
+			if err := http.ListenAndServe(conf.ApiAddr, app.Mux()); err != nil {
+				lgr.Fatalf("[ERROR] unable to start api %v", err)
+			}
+		}()
+	}
 
 	go func() {
-		err = bot.StartLongPolling(application.Handle)
+		err = bot.StartLongPolling(app.Bot.Handle)
 		if err != nil {
 			lgr.Fatalf("[ERROR] unable to start app")
 		}
@@ -57,6 +84,71 @@ func main() {
 	<-sigs
 }
 
+// Application holds the entry points sharing the same services: the bot, the job scheduler, the HTTP API
+// and the Web App backend.
+type Application struct {
+	Bot       *bot_handler.BotApp
+	Scheduler *scheduler.Scheduler
+	Api       http.Handler
+	WebApp    http.Handler
+	Relay     *webapp.Relay
+}
+
+// Mux serves the HTTP API and the Web App backend on the same address.
+func (a *Application) Mux() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/api/", a.Api)
+	mux.Handle("/webapp/", a.WebApp)
+	return mux
+}
+
+func NewApplication(pgRepository *dao.Repository, bot *tgbot.Bot) *Application {
+	events := service.NewEventBus()
+	roomService := service.NewRoomService(pgRepository, events)
+	rateService := service.NewRateService(pgRepository, events)
+	taskService := service.NewTaskService(pgRepository, events)
+	access := service.NewAccessPolicy(pgRepository)
+	tokenService := service.NewTokenService(pgRepository)
+	hub := webapp.NewHub()
+	relay := webapp.NewRelay(pgRepository, hub)
+	events.Subscribe(relay.Handle)
+	jobScheduler := scheduler.NewScheduler(pgRepository)
+	dispatcher := webhook.NewDispatcher(pgRepository, jobScheduler, globalWebhooks())
+	events.Subscribe(dispatcher.Handle)
+
+	viewSender := view.NewView(pgRepository, pgRepository, pgRepository, taskService, rateService, bot, conf.WebAppUrl)
+	application := bot_handler.NewBotApp(viewSender,
+		roomService,
+		taskService,
+		rateService,
+		access,
+		jobScheduler,
+		service.NewTrackerService(pgRepository, conf.TrackerSecret),
+		service.NewWebhookService(pgRepository),
+		tokenService,
+		export.NewExporter(roomService, taskService, rateService))
+	events.Subscribe(application.HandleEvent)
+
+	return &Application{
+		Bot:       application,
+		Scheduler: jobScheduler,
+		Api:       api.NewServer(roomService, taskService, rateService, access, tokenService),
+		WebApp:    webapp.NewServer(roomService, taskService, rateService, access, conf.TgToken, application.AfterWebAppRate, hub),
+		Relay:     relay,
+	}
+}
+
+// globalWebhooks are deployment-wide webhooks from the config, they receive events of every room.
+func globalWebhooks() []model.Webhook {
+	var hooks []model.Webhook
+	for _, url := range conf.WebhookUrls {
+		if url = strings.TrimSpace(url); url != "" {
+			hooks = append(hooks, model.Webhook{Url: url, Secret: conf.WebhookSecret})
+		}
+	}
+	return hooks
+}
+
 func PgConnInit() *sqlx.DB {
 
 	dsn := GetPgDsn()
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gotestbot/sdk/tgbot"
+	"os"
+)
+
+// runSetWebhook is the set-webhook subcommand, it registers the webhook mode entry point with the update types
+// the bot needs:
+//
+//	app set-webhook -url <url of Handler>
+func runSetWebhook(args []string) int {
+	flags := flag.NewFlagSet("set-webhook", flag.ContinueOnError)
+	link := flags.String("url", "", "public url of the webhook handler")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *link == "" {
+		fmt.Fprintln(os.Stderr, "set-webhook: -url is required")
+		flags.Usage()
+		return 2
+	}
+
+	bot, err := tgbot.NewBot(conf.TgToken, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "set-webhook: %v\n", err)
+		return 1
+	}
+	if err = bot.SetWebhook(*link); err != nil {
+		fmt.Fprintf(os.Stderr, "set-webhook: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "set-webhook: updates are sent to %v\n", *link)
+	return 0
+}
@@ -0,0 +1,2 @@
+ALTER TABLE room
+    DROP COLUMN scale;
@@ -0,0 +1,2 @@
+ALTER TABLE room
+    ADD COLUMN scale VARCHAR NOT NULL DEFAULT 'FIBONACCI';
@@ -0,0 +1,2 @@
+ALTER TABLE rate
+    DROP COLUMN kind;
@@ -0,0 +1,2 @@
+ALTER TABLE rate
+    ADD COLUMN kind VARCHAR NOT NULL DEFAULT 'ESTIMATE';
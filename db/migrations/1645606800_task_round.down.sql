@@ -0,0 +1,7 @@
+DROP INDEX rate_task_id_round_idx;
+
+ALTER TABLE rate
+    DROP COLUMN round;
+
+ALTER TABLE task
+    DROP COLUMN round;
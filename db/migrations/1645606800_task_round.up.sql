@@ -0,0 +1,7 @@
+ALTER TABLE task
+    ADD COLUMN round INT NOT NULL DEFAULT 1;
+
+ALTER TABLE rate
+    ADD COLUMN round INT NOT NULL DEFAULT 1;
+
+CREATE INDEX rate_task_id_round_idx ON rate (task_id, round);
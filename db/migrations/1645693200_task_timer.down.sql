@@ -0,0 +1,7 @@
+ALTER TABLE task
+    DROP COLUMN chat_id,
+    DROP COLUMN message_id,
+    DROP COLUMN published_date;
+
+ALTER TABLE room
+    DROP COLUMN timer_seconds;
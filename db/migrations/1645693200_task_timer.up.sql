@@ -0,0 +1,7 @@
+ALTER TABLE room
+    ADD COLUMN timer_seconds INT NOT NULL DEFAULT 0;
+
+ALTER TABLE task
+    ADD COLUMN chat_id        BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN message_id     INT    NOT NULL DEFAULT 0,
+    ADD COLUMN published_date TIMESTAMP;
@@ -0,0 +1,15 @@
+CREATE TABLE job
+(
+    id            UUID PRIMARY KEY,
+    kind          VARCHAR   NOT NULL,
+    payload       JSONB     NOT NULL,
+    status        VARCHAR   NOT NULL,
+    run_at        TIMESTAMP NOT NULL,
+    attempts      INT       NOT NULL DEFAULT 0,
+    max_attempts  INT       NOT NULL DEFAULT 3,
+    last_error    VARCHAR   NOT NULL DEFAULT '',
+    created_date  TIMESTAMP NOT NULL,
+    finished_date TIMESTAMP
+);
+
+CREATE INDEX job_status_run_at_idx ON job (status, run_at);
@@ -0,0 +1,3 @@
+ALTER TABLE room
+    DROP COLUMN reveal_policy,
+    DROP COLUMN reveal_quorum;
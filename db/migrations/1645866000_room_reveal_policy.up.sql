@@ -0,0 +1,3 @@
+ALTER TABLE room
+    ADD COLUMN reveal_policy VARCHAR NOT NULL DEFAULT 'ALL',
+    ADD COLUMN reveal_quorum INT     NOT NULL DEFAULT 0;
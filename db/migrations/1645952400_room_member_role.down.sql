@@ -0,0 +1,2 @@
+ALTER TABLE room_member
+    DROP COLUMN role;
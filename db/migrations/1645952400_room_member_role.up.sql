@@ -0,0 +1,8 @@
+ALTER TABLE room_member
+    ADD COLUMN role VARCHAR NOT NULL DEFAULT 'VOTER';
+
+UPDATE room_member rm
+SET role = 'FACILITATOR'
+FROM room r
+WHERE r.id = rm.room_id
+  AND r.user_id = rm.user_id;
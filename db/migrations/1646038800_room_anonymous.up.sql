@@ -0,0 +1,2 @@
+ALTER TABLE room
+    ADD COLUMN anonymous BOOLEAN NOT NULL DEFAULT FALSE;
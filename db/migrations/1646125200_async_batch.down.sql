@@ -0,0 +1,7 @@
+ALTER TABLE task
+    DROP COLUMN batch_id;
+
+DROP TABLE batch;
+
+ALTER TABLE room
+    DROP COLUMN mode;
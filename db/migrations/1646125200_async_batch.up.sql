@@ -0,0 +1,17 @@
+ALTER TABLE room
+    ADD COLUMN mode VARCHAR NOT NULL DEFAULT 'LIVE';
+
+CREATE TABLE batch
+(
+    id           UUID PRIMARY KEY,
+    room_id      UUID      NOT NULL,
+    deadline     TIMESTAMP NOT NULL,
+    status       VARCHAR   NOT NULL,
+    created_date TIMESTAMP NOT NULL,
+    FOREIGN KEY (room_id) REFERENCES room (id)
+);
+
+ALTER TABLE task
+    ADD COLUMN batch_id UUID REFERENCES batch (id);
+
+CREATE INDEX task_batch_id_idx ON task (batch_id);
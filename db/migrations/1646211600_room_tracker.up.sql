@@ -0,0 +1,11 @@
+CREATE TABLE room_tracker
+(
+    room_id      UUID PRIMARY KEY,
+    kind         VARCHAR   NOT NULL,
+    base_url     VARCHAR   NOT NULL DEFAULT '',
+    user_name    VARCHAR   NOT NULL DEFAULT '',
+    token        VARCHAR   NOT NULL,
+    points_field VARCHAR   NOT NULL,
+    created_date TIMESTAMP NOT NULL,
+    FOREIGN KEY (room_id) REFERENCES room (id)
+);
@@ -0,0 +1,27 @@
+CREATE TABLE webhook
+(
+    id           UUID PRIMARY KEY,
+    room_id      UUID      NOT NULL,
+    url          VARCHAR   NOT NULL,
+    secret       VARCHAR   NOT NULL,
+    created_date TIMESTAMP NOT NULL,
+    FOREIGN KEY (room_id) REFERENCES room (id)
+);
+
+CREATE TABLE webhook_delivery
+(
+    id             UUID PRIMARY KEY,
+    webhook_id     UUID,
+    url            VARCHAR   NOT NULL,
+    event          VARCHAR   NOT NULL,
+    payload        JSONB     NOT NULL,
+    status         VARCHAR   NOT NULL,
+    attempts       INT       NOT NULL DEFAULT 0,
+    response_code  INT       NOT NULL DEFAULT 0,
+    last_error     VARCHAR   NOT NULL DEFAULT '',
+    created_date   TIMESTAMP NOT NULL,
+    delivered_date TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES webhook (id) ON DELETE SET NULL
+);
+
+CREATE INDEX webhook_room_id_idx ON webhook (room_id);
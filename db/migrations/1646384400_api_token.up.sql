@@ -0,0 +1,10 @@
+CREATE TABLE api_token
+(
+    token_hash     VARCHAR PRIMARY KEY,
+    user_id        BIGINT    NOT NULL,
+    created_date   TIMESTAMP NOT NULL,
+    last_used_date TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES profile (user_id)
+);
+
+CREATE INDEX api_token_user_id_idx ON api_token (user_id);
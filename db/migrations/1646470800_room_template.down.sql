@@ -0,0 +1,2 @@
+DROP TABLE room_template_member;
+DROP TABLE room_template;
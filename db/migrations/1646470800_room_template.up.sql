@@ -0,0 +1,26 @@
+CREATE TABLE room_template
+(
+    id            UUID PRIMARY KEY,
+    user_id       BIGINT    NOT NULL,
+    name          VARCHAR   NOT NULL,
+    chat_id       BIGINT    NOT NULL DEFAULT 0,
+    scale         VARCHAR   NOT NULL,
+    timer_seconds INTEGER   NOT NULL DEFAULT 0,
+    reveal_policy VARCHAR   NOT NULL,
+    reveal_quorum INTEGER   NOT NULL DEFAULT 0,
+    anonymous     BOOLEAN   NOT NULL DEFAULT FALSE,
+    mode          VARCHAR   NOT NULL DEFAULT 'LIVE',
+    created_date  TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES profile (user_id)
+);
+
+CREATE TABLE room_template_member
+(
+    template_id UUID    NOT NULL,
+    user_id     BIGINT  NOT NULL,
+    role        VARCHAR NOT NULL,
+    PRIMARY KEY (template_id, user_id),
+    FOREIGN KEY (template_id) REFERENCES room_template (id) ON DELETE CASCADE
+);
+
+CREATE INDEX room_template_user_id_idx ON room_template (user_id);
@@ -0,0 +1,7 @@
+DROP INDEX room_chat_id_idx;
+
+ALTER TABLE room_template
+    DROP COLUMN auto_join;
+
+ALTER TABLE room
+    DROP COLUMN auto_join;
@@ -0,0 +1,7 @@
+ALTER TABLE room
+    ADD COLUMN auto_join BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE room_template
+    ADD COLUMN auto_join BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX room_chat_id_idx ON room (chat_id);
@@ -0,0 +1,4 @@
+DROP INDEX job_status_locked_until_idx;
+
+ALTER TABLE job
+    DROP COLUMN locked_until;
@@ -0,0 +1,4 @@
+ALTER TABLE job
+    ADD COLUMN locked_until TIMESTAMP;
+
+CREATE INDEX job_status_locked_until_idx ON job (status, locked_until);
@@ -0,0 +1,5 @@
+ALTER TABLE room_template
+    DROP COLUMN present_hours;
+
+ALTER TABLE room
+    DROP COLUMN present_hours;
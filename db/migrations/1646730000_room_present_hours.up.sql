@@ -0,0 +1,5 @@
+ALTER TABLE room
+    ADD COLUMN present_hours INT NOT NULL DEFAULT 3;
+
+ALTER TABLE room_template
+    ADD COLUMN present_hours INT NOT NULL DEFAULT 3;
@@ -12,6 +12,7 @@ require (
 	github.com/jackc/pgx/v4 v4.15.0
 	github.com/jmoiron/sqlx v1.3.4
 	github.com/pkg/errors v0.9.1
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
 )
 
 require (
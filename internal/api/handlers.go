@@ -0,0 +1,298 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"gotestbot/internal/httpjson"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 100
+)
+
+type roomResponse struct {
+	Id           string             `json:"id"`
+	Name         string             `json:"name"`
+	Status       model.RoomStatus   `json:"status"`
+	OwnerId      int64              `json:"owner_id"`
+	Scale        model.Scale        `json:"scale"`
+	Mode         model.RoomMode     `json:"mode"`
+	Anonymous    bool               `json:"anonymous"`
+	RevealPolicy model.RevealPolicy `json:"reveal_policy"`
+	TimerSeconds int                `json:"timer_seconds"`
+	CreatedDate  time.Time          `json:"created_date"`
+	Members      []memberResponse   `json:"members,omitempty"`
+}
+
+type memberResponse struct {
+	UserId   int64            `json:"user_id"`
+	Name     string           `json:"name"`
+	UserName string           `json:"user_name"`
+	Role     model.MemberRole `json:"role"`
+}
+
+type taskResponse struct {
+	Id            string         `json:"id"`
+	RoomId        string         `json:"room_id"`
+	Name          string         `json:"name"`
+	Url           string         `json:"url"`
+	Grade         *string        `json:"grade"`
+	Points        int32          `json:"points"`
+	Finished      bool           `json:"finished"`
+	Round         int            `json:"round"`
+	CreatedDate   time.Time      `json:"created_date"`
+	PublishedDate *time.Time     `json:"published_date"`
+	Votes         *int           `json:"votes,omitempty"`
+	Rates         []rateResponse `json:"rates,omitempty"`
+	Stats         *statsResponse `json:"stats,omitempty"`
+}
+
+// rateResponse is a vote. UserId is omitted in anonymous rooms for everyone except the owner and facilitators.
+type rateResponse struct {
+	UserId *int64         `json:"user_id,omitempty"`
+	Value  string         `json:"value"`
+	Points int32          `json:"points"`
+	Kind   model.RateKind `json:"kind"`
+}
+
+type statsResponse struct {
+	Votes       int             `json:"votes"`
+	Abstentions int             `json:"abstentions"`
+	Mean        float64         `json:"mean"`
+	Median      string          `json:"median"`
+	Mode        string          `json:"mode"`
+	Min         string          `json:"min"`
+	Max         string          `json:"max"`
+	Consensus   model.Consensus `json:"consensus"`
+}
+
+type createTaskRequest struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+func toRoomResponse(room model.Room) roomResponse {
+	return roomResponse{
+		Id:           room.Id.String(),
+		Name:         room.Name,
+		Status:       room.Status,
+		OwnerId:      room.UserId,
+		Scale:        room.Scale,
+		Mode:         room.Mode,
+		Anonymous:    room.Anonymous,
+		RevealPolicy: room.RevealPolicy,
+		TimerSeconds: room.Timer,
+		CreatedDate:  room.CreatedDate,
+	}
+}
+
+func toTaskResponse(room model.Room, task model.Task) taskResponse {
+	resp := taskResponse{
+		Id:            task.Id.String(),
+		RoomId:        task.RoomId.String(),
+		Name:          task.Name,
+		Url:           task.Url,
+		Points:        task.Grade,
+		Finished:      task.Finished,
+		Round:         task.Round,
+		CreatedDate:   task.CreatedDate,
+		PublishedDate: task.PublishedDate,
+	}
+	if task.Grade > 0 {
+		grade := room.Scale.Label(task.Grade)
+		resp.Grade = &grade
+	}
+	return resp
+}
+
+// getRooms lists rooms the user owns or is a member of.
+func (s *Server) getRooms(userId int64, _ []string, _ *http.Request) (int, interface{}, error) {
+	rooms, err := s.roomService.GetRoomsByMemberId(userId)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := make([]roomResponse, 0, len(rooms))
+	for _, room := range rooms {
+		resp = append(resp, toRoomResponse(room))
+	}
+	return http.StatusOK, resp, nil
+}
+
+func (s *Server) getRoom(userId int64, path []string, _ *http.Request) (int, interface{}, error) {
+	room, err := s.viewRoom(userId, path[1])
+	if err != nil {
+		return 0, nil, err
+	}
+	members, err := s.roomService.GetMembersByRoomId(room.Id.String())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp := toRoomResponse(room)
+	resp.Members = make([]memberResponse, 0, len(members))
+	for _, m := range members {
+		resp.Members = append(resp.Members, memberResponse{UserId: m.UserId, Name: m.DisplayName, UserName: m.UserName, Role: m.Role})
+	}
+	return http.StatusOK, resp, nil
+}
+
+// getTasks lists tasks of the room with grades, paginated with offset and limit query parameters.
+func (s *Server) getTasks(userId int64, path []string, req *http.Request) (int, interface{}, error) {
+	room, err := s.viewRoom(userId, path[1])
+	if err != nil {
+		return 0, nil, err
+	}
+	offset, limit, err := pagination(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	tasks, err := s.taskService.GetTasksByRoomIdAndPagination(room.Id.String(), offset, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := make([]taskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		resp = append(resp, toTaskResponse(room, task))
+	}
+	return http.StatusOK, resp, nil
+}
+
+// getTask returns the task with the votes of the current round. Until the task is revealed only the number of votes is known.
+func (s *Server) getTask(userId int64, path []string, _ *http.Request) (int, interface{}, error) {
+	taskId, err := parseId(path[1])
+	if err != nil {
+		return 0, nil, err
+	}
+	task, err := s.taskService.GetTaskById(taskId)
+	if err != nil {
+		return 0, nil, err
+	}
+	room, err := s.viewRoom(userId, task.RoomId.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	rounds, err := s.rateService.GetRoundStats(taskId)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp := toTaskResponse(room, task)
+	if len(rounds) == 0 {
+		return http.StatusOK, resp, nil
+	}
+	current := rounds[len(rounds)-1]
+	votes := len(current.Rates)
+	resp.Votes = &votes
+	if !task.Finished {
+		return http.StatusOK, resp, nil
+	}
+
+	showVoters := !room.Anonymous || s.access.Check(service.PermGrade, userId, room.Id.String()) == nil
+	resp.Rates = make([]rateResponse, 0, len(current.Rates))
+	for _, rate := range current.Rates {
+		r := rateResponse{Value: room.Scale.RateLabel(rate), Points: rate.Sum, Kind: rate.Kind}
+		if showVoters {
+			voter := rate.UserId
+			r.UserId = &voter
+		}
+		resp.Rates = append(resp.Rates, r)
+	}
+	resp.Stats = &statsResponse{
+		Votes:       current.Votes,
+		Abstentions: current.Abstentions,
+		Mean:        current.Mean,
+		Median:      room.Scale.Label(current.Median),
+		Mode:        room.Scale.Label(current.Mode),
+		Min:         room.Scale.Label(current.Min),
+		Max:         room.Scale.Label(current.Max),
+		Consensus:   current.Consensus,
+	}
+	return http.StatusOK, resp, nil
+}
+
+func (s *Server) createTask(userId int64, path []string, req *http.Request) (int, interface{}, error) {
+	room, err := s.checkRoom(service.PermAddTask, userId, path[1])
+	if err != nil {
+		return 0, nil, err
+	}
+	if room.Status == model.Finished {
+		return 0, nil, httpjson.InvalidRequest("room is finished")
+	}
+
+	var body createTaskRequest
+	if err = json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return 0, nil, httpjson.InvalidRequest("malformed json body")
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		return 0, nil, httpjson.InvalidRequest("name is required")
+	}
+
+	task := model.Task{
+		Id:          uuid.New(),
+		Name:        body.Name,
+		Url:         strings.TrimSpace(body.Url),
+		RoomId:      room.Id,
+		CreatedDate: time.Now(),
+	}
+	if err = s.taskService.SaveTask(task); err != nil {
+		return 0, nil, err
+	}
+	task, err = s.taskService.GetTaskById(task.Id.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, toTaskResponse(room, task), nil
+}
+
+// finishRoom finishes the planning, finishing an already finished room changes nothing.
+func (s *Server) finishRoom(userId int64, path []string, _ *http.Request) (int, interface{}, error) {
+	room, err := s.checkRoom(service.PermFinishRoom, userId, path[1])
+	if err != nil {
+		return 0, nil, err
+	}
+	room, err = s.roomService.FinishRoom(room.Id.String(), userId)
+	if err != nil && err != service.ErrRoomFinished {
+		return 0, nil, err
+	}
+	return http.StatusOK, toRoomResponse(room), nil
+}
+
+func (s *Server) viewRoom(userId int64, roomId string) (model.Room, error) {
+	return s.checkRoom(service.PermViewRoom, userId, roomId)
+}
+
+// checkRoom loads the room after the permission check, so rooms of others are not revealed by their existence.
+func (s *Server) checkRoom(perm service.Permission, userId int64, roomId string) (model.Room, error) {
+	roomId, err := parseId(roomId)
+	if err != nil {
+		return model.Room{}, err
+	}
+	if err = s.access.Check(perm, userId, roomId); err != nil {
+		return model.Room{}, err
+	}
+	return s.roomService.GetRoomById(roomId)
+}
+
+func pagination(req *http.Request) (offset int, limit int, err error) {
+	limit = defaultLimit
+	query := req.URL.Query()
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, httpjson.InvalidRequest("offset must be a non-negative number")
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, httpjson.InvalidRequest("limit must be between 1 and 100")
+		}
+	}
+	return offset, limit, nil
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"database/sql"
+	_ "embed" //for the OpenAPI description
+	"github.com/go-pkgz/lgr"
+	"github.com/google/uuid"
+	"gotestbot/internal/httpjson"
+	"gotestbot/internal/service"
+	"net/http"
+	"strings"
+)
+
+const prefix = "/api/v1/"
+
+//go:embed openapi.yaml
+var openApi []byte
+
+// Server is the HTTP JSON API. Every request except the OpenAPI description is authenticated
+// with a token issued by the bot: "Authorization: Bearer <token>".
+type Server struct {
+	roomService *service.RoomService
+	taskService *service.TaskService
+	rateService *service.RateService
+	access      *service.AccessPolicy
+	tokens      *service.TokenService
+}
+
+func NewServer(roomService *service.RoomService, taskService *service.TaskService, rateService *service.RateService,
+	access *service.AccessPolicy, tokens *service.TokenService) *Server {
+	return &Server{
+		roomService: roomService,
+		taskService: taskService,
+		rateService: rateService,
+		access:      access,
+		tokens:      tokens,
+	}
+}
+
+// handlerFunc is an authenticated handler, path holds the parts of the path after the prefix.
+type handlerFunc func(userId int64, path []string, req *http.Request) (int, interface{}, error)
+
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		httpjson.WriteError(rw, http.StatusNotFound, "not found")
+		return
+	}
+	path := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, prefix), "/"), "/")
+
+	if len(path) == 1 && path[0] == "openapi.yaml" && req.Method == http.MethodGet {
+		rw.Header().Set("Content-Type", "application/yaml")
+		_, _ = rw.Write(openApi)
+		return
+	}
+
+	handler, allowed := s.route(req.Method, path)
+	if handler == nil {
+		if allowed {
+			httpjson.WriteError(rw, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		httpjson.WriteError(rw, http.StatusNotFound, "not found")
+		return
+	}
+
+	userId, err := s.tokens.Authenticate(bearerToken(req))
+	if err == service.ErrInvalidToken {
+		httpjson.WriteError(rw, http.StatusUnauthorized, "invalid api token, issue a new one with /token command in the bot")
+		return
+	}
+	if err != nil {
+		lgr.Printf("[ERROR] unable to authenticate api request, %v", err)
+		httpjson.WriteError(rw, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	status, body, err := handler(userId, path, req)
+	if err != nil {
+		httpjson.HandleError(rw, "api", userId, req, err)
+		return
+	}
+	httpjson.Write(rw, status, body)
+}
+
+// route finds the handler by method and path. allowed reports that the path exists but with another method.
+func (s *Server) route(method string, path []string) (handlerFunc, bool) {
+	var handlers map[string]handlerFunc
+	switch {
+	case len(path) == 1 && path[0] == "rooms":
+		handlers = map[string]handlerFunc{http.MethodGet: s.getRooms}
+	case len(path) == 2 && path[0] == "rooms":
+		handlers = map[string]handlerFunc{http.MethodGet: s.getRoom}
+	case len(path) == 3 && path[0] == "rooms" && path[2] == "tasks":
+		handlers = map[string]handlerFunc{http.MethodGet: s.getTasks, http.MethodPost: s.createTask}
+	case len(path) == 3 && path[0] == "rooms" && path[2] == "finish":
+		handlers = map[string]handlerFunc{http.MethodPost: s.finishRoom}
+	case len(path) == 2 && path[0] == "tasks":
+		handlers = map[string]handlerFunc{http.MethodGet: s.getTask}
+	default:
+		return nil, false
+	}
+	return handlers[method], true
+}
+
+// parseId validates an id from the path, malformed ids are reported as not found.
+func parseId(id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return "", sql.ErrNoRows
+	}
+	return id, nil
+}
+
+func bearerToken(req *http.Request) string {
+	header := req.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package bot_handler
+
+import (
+	log "github.com/go-pkgz/lgr"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service"
+	"gotestbot/sdk/tgbot"
+)
+
+var permissionTitles = map[service.Permission]string{
+	service.PermReveal:     "раскрыть оценки",
+	service.PermRevote:     "запустить переголосование",
+	service.PermGrade:      "выставить итоговую оценку",
+	service.PermAddTask:    "добавлять задачи",
+	service.PermNextTask:   "публиковать задачи",
+	service.PermFinishRoom: "завершить планирование",
+	service.PermManageRoom: "изменять настройки комнаты",
+	service.PermViewRoom:   "просматривать комнату",
+	service.PermAdminRoom:  "управлять комнатой",
+}
+
+// authorize checks the permission and answers the user when it is missing. Every denial is logged for audit.
+func (b *BotApp) authorize(u *tgbot.Update, perm service.Permission, roomId string) bool {
+	err := b.access.Check(perm, u.GetUserId(), roomId)
+	if err == nil {
+		return true
+	}
+
+	var denied *service.AccessDeniedError
+	if !errors.As(err, &denied) {
+		log.Printf("[ERROR] unable to check permission %v for user %d in room %v, %v", perm, u.GetUserId(), roomId, err)
+		b.sendErrorMessage(u)
+		return false
+	}
+
+	log.Printf("[WARN] audit: %v", denied)
+	text := "❗️ Только ведущий комнаты может " + permissionTitles[perm]
+	switch denied.Reason {
+	case service.DenyObserver:
+		text = "👀 Наблюдатели не голосуют"
+	case service.DenyNotMember:
+		text = "❗️ Сначала присоединитесь к комнате"
+	case service.DenyNotOwner:
+		text = "❗️ Только владелец комнаты может " + permissionTitles[perm]
+	}
+
+	if u.IsButton() {
+		_, _ = b.view.ErrorMessage(u, text)
+	} else {
+		_, _ = b.view.ErrorMessageText(text, u)
+	}
+	return false
+}
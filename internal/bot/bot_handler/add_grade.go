@@ -3,8 +3,8 @@ package bot_handler
 import (
 	"github.com/go-pkgz/lgr"
 	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
 	"gotestbot/sdk/tgbot"
-	"strconv"
 )
 
 func (b *BotApp) HandleAddTaskGrade(u *tgbot.Update) {
@@ -12,6 +12,9 @@ func (b *BotApp) HandleAddTaskGrade(u *tgbot.Update) {
 	if u.HasAction(view.ActionFinishTaskRate) {
 		roomId := u.GetButton().GetData("roomId")
 		taskId := u.GetButton().GetData("taskId")
+		if !b.authorize(u, service.PermGrade, roomId) {
+			return
+		}
 
 		u.StartChain(string(view.ActionFinishTaskRate)).
 			StartChainStep("SET_GRADE").
@@ -25,21 +28,30 @@ func (b *BotApp) HandleAddTaskGrade(u *tgbot.Update) {
 
 	switch u.GetChainStep() {
 	case "SET_GRADE":
-		grade := u.GetText()
-		gradeInt64, err := strconv.ParseInt(grade, 10, 32)
+		roomId := u.GetChainData("roomId")
+		room, err := b.roomService.GetRoomById(roomId)
 		if err != nil {
-			lgr.Printf("[ERROR] unable to get room by roomId: %d, $v", grade, err)
+			lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+			_, _ = b.view.ErrorMessageText("❗️ Ошибка присваивания итоговой оценки задаче", u)
+			return
+		}
 
+		grade, ok := room.Scale.ParseGrade(u.GetText())
+		if !ok {
+			_, _ = b.view.ErrorMessageText("❗️ Введите значение из шкалы комнаты или число", u)
+			return
 		}
 
 		taskId := u.GetChainData("taskId")
-		if err = b.taskService.SetGradeTask(int32(gradeInt64), taskId); err != nil {
+		if err = b.taskService.SetGradeTask(grade, taskId); err != nil {
 			lgr.Printf("[ERROR] unable SetGradeTask by taskId: %v, $v", taskId, err)
 			_, _ = b.view.ErrorMessageText("❗️ Ошибка присваивания итоговой оценки задаче", u)
 			return
 		}
-		roomId := u.GetChainData("roomId")
 		_, _ = b.view.ShowRoomView("Итоговая оценка успешно присвоена\n\n", roomId, u)
+		_, _ = b.view.ShowResultChart(taskId, roomId)
+		b.view.ShowTaskGraded(taskId, roomId, u.GetUser())
+		b.scheduleWriteBack(taskId, roomId)
 		u.FinishChain().FlushChatInfo()
 
 	default:
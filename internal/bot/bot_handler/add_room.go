@@ -33,31 +33,47 @@ func (b *BotApp) HandleAddRoom(u *tgbot.Update) {
 			u.Update.Message.NewChatMembers[0].UserName == b.view.GetMe().UserName {
 
 			u.AddChainData("chatId", strconv.FormatInt(u.GetChatId(), 10))
-			_, _ = b.view.AddSettingRoom(fmt.Sprintf("Бот успешно привязан к чату - *%v*\n\n", u.Message.Chat.Title), u)
+			_, _ = b.view.AddScaleRoom(fmt.Sprintf("Бот успешно привязан к чату - *%v*\n\n", u.Message.Chat.Title), u)
 
 		} else if u.HasAction(view.ActionBotAdded) {
-			_, _ = b.view.AddSettingRoom("", u)
+			_, _ = b.view.AddScaleRoom("", u)
 		}
-		u.StartChainStep("SETTING").FlushChatInfo()
+		u.StartChainStep("SCALE").FlushChatInfo()
 		_, _ = b.view.NewDeleteMessage(u.GetChatId(), u.GetMessageId())
 
+	case "SCALE":
+		if !u.HasAction(view.ActionRoomSettingScale) {
+			return
+		}
+		u.StartChainStep("SETTING").
+			AddChainData("scale", u.GetButton().GetData("scale")).
+			FlushChatInfo()
+		_, _ = b.view.AddSettingRoom("", u)
+
 	case "SETTING":
+		if !u.HasAction(view.ActionRoomSettingTimes) {
+			return
+		}
 		roomId := uuid.New()
 		chatId64, _ := strconv.ParseInt(u.GetChainData("chatId"), 10, 64)
+		timer, _ := strconv.Atoi(u.GetButton().GetData("timer"))
 		if err := b.roomService.SaveRoom(model.Room{
-			Id:          roomId,
-			Name:        u.GetChainData("name"),
-			UserId:      u.GetUser().UserId,
-			ChatId:      chatId64,
-			Status:      model.New,
-			CreatedDate: time.Now(),
+			Id:           roomId,
+			Name:         u.GetChainData("name"),
+			UserId:       u.GetUser().UserId,
+			ChatId:       chatId64,
+			Scale:        model.Scale(u.GetChainData("scale")),
+			Timer:        timer,
+			RevealPolicy: model.RevealAll,
+			Status:       model.New,
+			CreatedDate:  time.Now(),
 		}); err != nil {
 			lgr.Printf("[ERROR] SaveRoom not")
 			b.sendErrorMessage(u)
 			return
 		}
 
-		if err1 := b.roomService.SaveRoomMember(u.GetUser().UserId, roomId.String()); err1 != nil {
+		if err1 := b.roomService.SaveRoomMember(u.GetUser().UserId, roomId.String(), model.RoleFacilitator); err1 != nil {
 			lgr.Printf("[ERROR] can not save room")
 			b.sendErrorMessage(u)
 			return
@@ -76,14 +92,8 @@ func (b *BotApp) HandleAddRoom(u *tgbot.Update) {
 		}
 
 	case "SEND_TO_CHAT":
-		if u.Update.Message != nil &&
-			u.Update.Message.ViaBot != nil &&
-			u.Update.Message.ReplyMarkup != nil &&
-			u.Update.Message.ReplyMarkup.InlineKeyboard != nil &&
-			u.Update.Message.ReplyMarkup.InlineKeyboard[0][0].Text == "Присоединиться" {
-
-			buttonId := u.Update.Message.ReplyMarkup.InlineKeyboard[0][0].CallbackData
-			room, err := b.roomService.GetRoomById(u.GetButtonById(*buttonId).GetData("roomId"))
+		if roomId, ok := sentRoomId(u); ok {
+			room, err := b.roomService.GetRoomById(roomId)
 			if err != nil {
 				lgr.Printf("[ERROR] ")
 				b.sendErrorMessage(u)
@@ -103,6 +113,20 @@ func (b *BotApp) HandleAddRoom(u *tgbot.Update) {
 	}
 }
 
+// sentRoomId returns the room of the message the user posted to a chat with the inline switch button.
+func sentRoomId(u *tgbot.Update) (string, bool) {
+	if u.Update.Message != nil &&
+		u.Update.Message.ViaBot != nil &&
+		u.Update.Message.ReplyMarkup != nil &&
+		u.Update.Message.ReplyMarkup.InlineKeyboard != nil &&
+		u.Update.Message.ReplyMarkup.InlineKeyboard[0][0].Text == "Присоединиться" {
+
+		buttonId := u.Update.Message.ReplyMarkup.InlineKeyboard[0][0].CallbackData
+		return u.GetButtonById(*buttonId).GetData("roomId"), true
+	}
+	return "", false
+}
+
 func (b *BotApp) sendErrorMessage(u *tgbot.Update) {
 	text := "Что-то пошло не так\n"
 	if u.IsButton() {
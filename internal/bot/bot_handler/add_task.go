@@ -3,11 +3,14 @@ package bot_handler
 import (
 	"fmt"
 	"github.com/go-pkgz/lgr"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/google/uuid"
 	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
 	"gotestbot/internal/service/model"
 	"gotestbot/sdk/tgbot"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +18,9 @@ func (b *BotApp) HandleAddTask(u *tgbot.Update) {
 
 	if u.HasAction(view.ActionCreateTask) {
 		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermAddTask, roomId) {
+			return
+		}
 		room, err := b.roomService.GetRoomById(roomId)
 		if err != nil {
 			lgr.Printf("[ERROR] unable to get room by roomId: %d, $v", roomId, err)
@@ -37,10 +43,35 @@ func (b *BotApp) HandleAddTask(u *tgbot.Update) {
 
 	switch u.GetChainStep() {
 	case "NAME":
+		roomId := u.GetChainData("roomId")
+		issue, err := b.trackerService.FindIssue(roomId, strings.TrimSpace(u.GetText()))
+		if err != nil {
+			lgr.Printf("[WARN] unable to find issue for room: %v, %v", roomId, err)
+			_, _ = b.view.ShowTrackerError(roomId, fmt.Sprintf("Не удалось получить задачу из трекера: %v", err))
+		}
+		if issue != nil {
+			u.StartChainStep("SETTING").
+				AddChainData("name", issue.Title).
+				AddChainData("url", issue.Url).
+				FlushChatInfo()
+			_, _ = b.view.AddSettingTask(fmt.Sprintf("🔗 %v: *%v*\n\n", issue.Key, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, issue.Title)), u)
+			return
+		}
 		u.StartChainStep("URL").AddChainData("name", u.GetText()).FlushChatInfo()
 		_, _ = b.view.AddTaskUrl(u)
 
 	case "URL":
+		roomId := u.GetChainData("roomId")
+		issue, err := b.trackerService.FindIssue(roomId, strings.TrimSpace(u.GetText()))
+		if err != nil {
+			lgr.Printf("[WARN] unable to find issue for room: %v, %v", roomId, err)
+			_, _ = b.view.ShowTrackerError(roomId, fmt.Sprintf("Не удалось получить задачу из трекера: %v", err))
+		}
+		if issue != nil {
+			u.StartChainStep("SETTING").AddChainData("url", issue.Url).FlushChatInfo()
+			_, _ = b.view.AddSettingTask(fmt.Sprintf("🔗 %v: *%v*\n\n", issue.Key, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, issue.Title)), u)
+			return
+		}
 		u.StartChainStep("SETTING").AddChainData("url", u.GetText()).FlushChatInfo()
 		_, _ = b.view.AddSettingTask("", u)
 
@@ -75,7 +106,7 @@ func (b *BotApp) HandleAddTask(u *tgbot.Update) {
 
 		switch {
 		case u.HasAction(view.ActionSaveAndSendTask):
-			msg, err := b.view.ShowTaskView(room.ChatId, takId.String(), roomId, u)
+			msg, err := b.publishTask(room, takId.String())
 			if err != nil {
 				_, _ = b.view.ErrorMessage(u, "Не получилось опубликовать задачу")
 			} else {
@@ -0,0 +1,58 @@
+package bot_handler
+
+import (
+	"fmt"
+	"github.com/go-pkgz/lgr"
+	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+)
+
+// HandleBulkAddTasks creates many tasks from one message. Every sent list is previewed, CONFIRM keeps the last one until it is saved.
+func (b *BotApp) HandleBulkAddTasks(u *tgbot.Update) {
+
+	if u.HasAction(view.ActionBulkCreateTasks) {
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermAddTask, roomId) {
+			return
+		}
+		room, err := b.roomService.GetRoomById(roomId)
+		if err != nil {
+			lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+			return
+		}
+		if room.Status == model.Finished {
+			_, _ = b.view.ErrorMessage(u, "❗️ Планирование уже завершено")
+			return
+		}
+
+		u.FinishChain().
+			StartChain(string(view.ActionBulkCreateTasks)).
+			StartChainStep("TEXT").AddChainData("roomId", roomId).FlushChatInfo()
+		_, _ = b.view.AddBulkTasks(roomId, u)
+		return
+	}
+
+	roomId := u.GetChainData("roomId")
+	switch {
+	case !u.IsButton():
+		drafts, invalid := service.ParseTaskLines(u.GetText())
+		u.StartChainStep("CONFIRM").AddChainData("text", u.GetText()).FlushChatInfo()
+		_, _ = b.view.ShowBulkTasksPreview(roomId, drafts, invalid, u)
+
+	case u.HasAction(view.ActionBulkSaveTasks) && u.GetChainStep() == "CONFIRM":
+		if !b.authorize(u, service.PermAddTask, roomId) {
+			return
+		}
+
+		drafts, _ := service.ParseTaskLines(u.GetChainData("text"))
+		if err := b.taskService.SaveDrafts(roomId, drafts); err != nil {
+			lgr.Printf("[ERROR] unable to save tasks of room: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		u.FinishChain().FlushChatInfo()
+		_, _ = b.view.ShowRoomView(fmt.Sprintf("✅ Добавлено задач: %d\n\n", len(drafts)), roomId, u)
+	}
+}
@@ -0,0 +1,22 @@
+package bot_handler
+
+import (
+	"github.com/go-pkgz/lgr"
+	"gotestbot/sdk/tgbot"
+)
+
+// HandleApiToken issues a new API token and revokes the previous one. Tokens are only sent to private chats.
+func (b *BotApp) HandleApiToken(u *tgbot.Update) {
+	if !u.Message.Chat.IsPrivate() {
+		_, _ = b.view.ErrorMessageText("🔑 Токен API выдается только в личном чате с ботом", u)
+		return
+	}
+
+	token, err := b.tokenService.Issue(u.GetUserId())
+	if err != nil {
+		lgr.Printf("[ERROR] unable to issue api token for user: %v, %v", u.GetUserId(), err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowApiToken(token, u)
+}
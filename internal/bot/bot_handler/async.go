@@ -0,0 +1,192 @@
+package bot_handler
+
+import (
+	"fmt"
+	log "github.com/go-pkgz/lgr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+	"strconv"
+	"time"
+)
+
+const JobBatchDeadline = "BATCH_DEADLINE"
+
+// HandlePublishBatch asks for a deadline first, then sends every not estimated task of the room as private ballots.
+func (b *BotApp) HandlePublishBatch(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermNextTask, roomId) {
+		return
+	}
+	hours, _ := strconv.Atoi(u.GetButton().GetData("hours"))
+	if hours <= 0 {
+		_, _ = b.view.AddBatchDeadline(roomId, u)
+		return
+	}
+
+	room, err := b.roomService.GetRoomById(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	if room.ChatId == 0 {
+		_, _ = b.view.ErrorMessage(u, "❗️ Сперва привяжите к комнате чат, в нем будут показаны результаты")
+		return
+	}
+
+	batch, tasks, err := b.taskService.PublishBatch(roomId, time.Now().Add(time.Duration(hours)*time.Hour))
+	if errors.Is(err, service.ErrNoTasks) {
+		_, _ = b.view.ErrorMessage(u, "❗️ Не найдено запланированных задач!")
+		return
+	} else if err != nil {
+		log.Printf("[ERROR] unable to publish batch for room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+
+	members, err := b.roomService.GetMembersByRoomId(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get members by roomId: %v, %v", roomId, err)
+	}
+	var failed []model.Member
+	for _, member := range members {
+		if member.IsObserver() {
+			continue
+		}
+		if !b.sendBallots(member.UserId, tasks, roomId) {
+			failed = append(failed, member)
+		}
+	}
+
+	err = b.scheduler.Schedule(JobBatchDeadline, batch.Deadline, model.JobPayload{
+		"batchId": batch.Id.String(),
+		"roomId":  roomId,
+	})
+	if err != nil {
+		log.Printf("[ERROR] unable to schedule batch deadline, batchId: %v, %v", batch.Id, err)
+	}
+
+	_, _ = b.view.ShowBatchPublished(room, batch, tasks, failed)
+	_, _ = b.view.ShowRoomView(fmt.Sprintf("📬 Разослано задач: %d\n\n", len(tasks)), roomId, u)
+}
+
+// sendBallots sends a ballot per task to the user. It fails when the user never started a chat with the bot.
+func (b *BotApp) sendBallots(userId int64, tasks []model.Task, roomId string) bool {
+	for _, task := range tasks {
+		if _, err := b.view.ShowBallotView(userId, task.Id.String(), roomId, nil); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// sendOpenBallots sends ballots of all open batches to a member who joined the room after they were published.
+func (b *BotApp) sendOpenBallots(userId int64, roomId string) {
+	batches, err := b.taskService.GetOpenBatches(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get open batches, roomId: %v, %v", roomId, err)
+		return
+	}
+	for _, batch := range batches {
+		tasks, err := b.taskService.GetTasksByBatchId(batch.Id.String())
+		if err != nil {
+			log.Printf("[ERROR] unable to get tasks of batch: %v, %v", batch.Id, err)
+			continue
+		}
+		b.sendBallots(userId, tasks, roomId)
+	}
+}
+
+// HandleBallotRate saves a vote from a private ballot and reveals the batch once every ballot is filled in.
+func (b *BotApp) HandleBallotRate(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	taskId := u.GetButton().GetData("taskId")
+	if !b.authorize(u, service.PermVote, roomId) {
+		return
+	}
+
+	room, err := b.roomService.GetRoomById(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	task, err := b.taskService.GetTaskById(taskId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get task by taskId: %v, %v", taskId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	if !task.BatchId.Valid || task.Finished {
+		_, _ = b.view.ErrorMessage(u, "❗️ Голосование по задаче уже завершено")
+		return
+	}
+
+	label := u.GetButton().GetData("card")
+	card, ok := room.Scale.Card(label)
+	if !ok || card.Kind == model.RateKindCoffee {
+		log.Printf("[WARN] card %q is not allowed in ballot of room %v", label, roomId)
+		_, _ = b.view.ErrorMessage(u, "❗️ Такой оценки нет в шкале комнаты")
+		return
+	}
+
+	rate := model.Rate{
+		Id:          uuid.New(),
+		UserId:      u.GetUserId(),
+		TaskId:      task.Id,
+		Sum:         card.Points,
+		Kind:        card.Kind,
+		CreatedDate: time.Now(),
+	}
+	if err = b.rateService.UpsertRate(rate); err != nil {
+		log.Printf("[ERROR] unable to save ballot rate, taskId: %v, %v", taskId, err)
+		_, _ = b.view.ErrorMessage(u, "Не получилось учесть ваш голос")
+		return
+	}
+	_, _ = b.view.ShowBallotView(0, taskId, roomId, u)
+
+	batchId := task.BatchId.UUID.String()
+	finished, err := b.taskService.BatchFinished(batchId)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return
+	}
+	if finished {
+		b.revealBatch(batchId, roomId)
+	}
+}
+
+// revealBatch shows results of every task of the batch in the room chat. Only the first caller reveals.
+func (b *BotApp) revealBatch(batchId, roomId string) {
+	tasks, err := b.taskService.RevealBatch(batchId)
+	if err != nil {
+		log.Printf("[ERROR] unable to reveal batch: %v, %v", batchId, err)
+		return
+	}
+	for _, task := range tasks {
+		taskId := task.Id.String()
+		rates, err := b.rateService.GetRatesByTaskId(taskId)
+		if err != nil {
+			log.Printf("[ERROR] unable to GetRatesByTaskId for taskId %v, %v", taskId, err)
+			continue
+		}
+		_, _ = b.view.ShowFinishedTaskView(taskId, roomId, rates, nil)
+		_, _ = b.view.ShowSetTaskGrade(taskId, roomId, 0)
+	}
+}
+
+// handleBatchDeadline reveals the batch when its deadline passes, unless everyone voted earlier.
+func (b *BotApp) handleBatchDeadline(payload model.JobPayload) error {
+	batchId, roomId := payload["batchId"], payload["roomId"]
+	batch, err := b.taskService.GetBatchById(batchId)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get batch for deadline, batchId: %v", batchId)
+	}
+	if batch.Status == model.BatchOpen {
+		b.revealBatch(batchId, roomId)
+	}
+	return nil
+}
@@ -3,8 +3,11 @@ package bot_handler
 import (
 	"fmt"
 	log "github.com/go-pkgz/lgr"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/google/uuid"
 	"gotestbot/internal/bot/view"
+	"gotestbot/internal/export"
+	"gotestbot/internal/scheduler"
 	"gotestbot/internal/service"
 	"gotestbot/internal/service/model"
 	"gotestbot/sdk/tgbot"
@@ -17,14 +20,31 @@ type BotApp struct {
 	roomService *service.RoomService
 	taskService *service.TaskService
 	rateService *service.RateService
+	access      *service.AccessPolicy
+	scheduler   *scheduler.Scheduler
+
+	trackerService *service.TrackerService
+	webhookService *service.WebhookService
+	tokenService   *service.TokenService
+	exporter       *export.Exporter
 }
 
-func NewBotApp(view *view.View, roomProv *service.RoomService, taskProv *service.TaskService, rateProv *service.RateService) *BotApp {
-	return &BotApp{view: view,
-		roomService: roomProv,
-		taskService: taskProv,
-		rateService: rateProv,
+func NewBotApp(view *view.View, roomProv *service.RoomService, taskProv *service.TaskService, rateProv *service.RateService,
+	access *service.AccessPolicy, scheduler *scheduler.Scheduler, trackerProv *service.TrackerService,
+	webhookProv *service.WebhookService, tokenProv *service.TokenService, exporter *export.Exporter) *BotApp {
+	app := &BotApp{view: view,
+		roomService:    roomProv,
+		taskService:    taskProv,
+		rateService:    rateProv,
+		access:         access,
+		scheduler:      scheduler,
+		trackerService: trackerProv,
+		webhookService: webhookProv,
+		tokenService:   tokenProv,
+		exporter:       exporter,
 	}
+	app.registerJobs()
+	return app
 }
 
 func (b *BotApp) Handle(u *tgbot.Update) {
@@ -34,25 +54,56 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 		u.FinishChain().FlushChatInfo()
 		_, _ = b.view.StartView(u)
 
+	case u.HasCommand("/token"):
+		b.HandleApiToken(u)
+
+	case u.MyChatMember != nil || u.GetMigrateToChatId() != 0:
+		b.HandleBotChat(u)
+
+	case u.GetLeftMember() != nil || len(u.GetJoinedMembers()) > 0:
+		b.HandleChatMembers(u)
+
+	case u.HasDocument() && u.Message.Chat.IsPrivate() || u.HasAction(view.ActionImportTasks):
+		b.HandleImportTasks(u)
+
 	case u.HasActionOrChain(view.ActionCreateTask):
 		b.HandleAddTask(u)
 
+	case u.HasAction(view.ActionBulkCreateTasks) || u.HasAction(view.ActionBulkSaveTasks) ||
+		u.HasChain(view.ActionBulkCreateTasks) && !u.IsButton():
+		b.HandleBulkAddTasks(u)
+
 	case u.HasActionOrChain(view.ActionAddRate):
 		roomId := u.GetButton().GetData("roomId")
 		taskId := u.GetButton().GetData("taskId")
-		sum := u.GetButton().GetData("sum")
 		parse, _ := uuid.Parse(taskId)
 
-		sumInt64, err := strconv.ParseInt(sum, 10, 32)
+		room, err := b.roomService.GetRoomById(roomId)
 		if err != nil {
-			log.Printf("[ERROR] failed to send health, %v", err)
+			log.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+
+		b.autoJoin(room, u)
+		if !b.authorize(u, service.PermVote, roomId) {
+			return
+		}
+
+		label := u.GetButton().GetData("card")
+		card, ok := room.Scale.Card(label)
+		if !ok {
+			log.Printf("[WARN] card %q is not on scale %v of room %v", label, room.Scale, roomId)
+			_, _ = b.view.ErrorMessage(u, "❗️ Такой оценки нет в шкале комнаты")
+			return
 		}
 
 		rate := model.Rate{
 			Id:          uuid.New(),
 			UserId:      u.GetUserId(),
 			TaskId:      parse,
-			Sum:         int32(sumInt64),
+			Sum:         card.Points,
+			Kind:        card.Kind,
 			CreatedDate: time.Now(),
 		}
 		if err = b.rateService.UpsertRate(rate); err != nil {
@@ -60,6 +111,9 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 			_, _ = b.view.ErrorMessage(u, "Не получилось учесть ваш голос")
 			return
 		}
+		if card.Kind == model.RateKindCoffee {
+			b.view.ShowCoffeeAlert(taskId, roomId, u)
+		}
 
 		finished, err := b.taskService.TaskFinished(taskId)
 		if err != nil {
@@ -67,31 +121,21 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 			return
 		}
 
+		// the last voter did not reveal the task, so it is revealed like by a timer and the grade goes to the owner
 		if finished {
-			rates, err := b.rateService.GetRatesByTaskId(taskId)
-			if err != nil {
-				log.Printf("[ERROR] unable to GetRatesByTaskId for taskId %d, %v", taskId, err)
-				return
-			}
-			_, _ = b.view.ShowFinishedTaskView(taskId, roomId, rates, u)
-			_, _ = b.view.ShowSetTaskGrade(taskId, roomId, u)
-
-			err = b.taskService.SetFinished(taskId)
-			if err != nil {
-				log.Printf("[ERROR] failed to send health, %v", err)
-				return
-			}
-
+			b.revealTask(taskId, roomId, nil)
 		} else {
-			//_, _ = b.view.ShowTaskTime(taskId, roomId, u)
 			_, _ = b.view.ShowTaskView(0, taskId, roomId, u)
 		}
 
 	case u.HasAction(view.ActionRevoteTaskRate):
 		roomId := u.GetButton().GetData("roomId")
 		taskId := u.GetButton().GetData("taskId")
+		if !b.authorize(u, service.PermRevote, roomId) {
+			return
+		}
 
-		err := b.rateService.DelRatesByTaskId(taskId)
+		err := b.taskService.StartNewRound(taskId)
 		if err != nil {
 			log.Printf("[ERROR] %v", err)
 			_, _ = b.view.ErrorMessage(u, "Не получилось рестартовать голосование")
@@ -102,6 +146,11 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 			log.Printf("[ERROR] unable to get room by roomId: %d %v", roomId, err)
 			return
 		}
+		// async rooms vote again with private ballots, the task goes to the next batch
+		if room.IsAsync() {
+			_, _ = b.view.AddBatchDeadline(roomId, u)
+			return
+		}
 		b.postTask(u, room.ChatId, taskId, roomId)
 
 	case u.HasAction(view.ActionShowRooms):
@@ -109,26 +158,147 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 
 	case u.HasAction(view.ActionShowRoom):
 		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermViewRoom, roomId) {
+			return
+		}
+		if u.HasChain(view.ActionBulkCreateTasks) {
+			u.FinishChain().FlushChatInfo()
+		}
 		_, _ = b.view.ShowRoomView("", roomId, u)
 
+	case u.HasAction(view.ActionShowRoomSettings):
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+		_, _ = b.view.ShowRoomSettings("", roomId, u)
+
+	case u.HasAction(view.ActionSetRoomTimer):
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+		timer, _ := strconv.Atoi(u.GetButton().GetData("timer"))
+		if err := b.roomService.SetTimerRoom(roomId, timer); err != nil {
+			log.Printf("[ERROR] unable to set timer for room: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		_, _ = b.view.ShowRoomSettings("", roomId, u)
+
+	case u.HasAction(view.ActionShowRoomAdmin) || u.HasAction(view.ActionRenameRoom) || u.HasAction(view.ActionRebindChat) ||
+		u.HasAction(view.ActionRemoveMember) || u.HasAction(view.ActionTransferRoom) || u.HasAction(view.ActionSetFacilitator) ||
+		(u.HasChain(view.ActionRenameRoom) || u.HasChain(view.ActionRebindChat)) && !u.IsButton():
+		b.HandleRoomAdmin(u)
+
+	case u.HasActionOrChain(view.ActionSetRevealPolicy):
+		b.HandleSetRevealPolicy(u)
+
+	case u.HasAction(view.ActionSetAnonymous):
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+		anonymous, _ := strconv.ParseBool(u.GetButton().GetData("anonymous"))
+		if err := b.roomService.SetAnonymousRoom(roomId, anonymous); err != nil {
+			log.Printf("[ERROR] unable to set anonymous for room: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		_, _ = b.view.ShowRoomSettings("", roomId, u)
+
+	case u.HasAction(view.ActionSetAutoJoin):
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+		autoJoin, _ := strconv.ParseBool(u.GetButton().GetData("autoJoin"))
+		if err := b.roomService.SetAutoJoinRoom(roomId, autoJoin); err != nil {
+			log.Printf("[ERROR] unable to set auto join for room: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		_, _ = b.view.ShowRoomSettings("", roomId, u)
+
+	case u.HasAction(view.ActionSetRoomMode):
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+		mode := model.RoomMode(u.GetButton().GetData("mode"))
+		if err := b.roomService.SetModeRoom(roomId, mode); err != nil {
+			log.Printf("[ERROR] unable to set mode for room: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		_, _ = b.view.ShowRoomSettings("", roomId, u)
+
+	case u.HasActionOrChain(view.ActionSetTracker):
+		b.HandleSetTracker(u)
+
+	case u.HasAction(view.ActionShowWebhooks) || u.HasAction(view.ActionDeleteWebhook) || u.HasAction(view.ActionAddWebhook) ||
+		u.HasChain(view.ActionAddWebhook) && !u.IsButton():
+		b.HandleWebhooks(u)
+
+	case u.HasAction(view.ActionCloneRoom) || u.HasChain(view.ActionCloneRoom) && !u.IsButton():
+		b.HandleCloneRoom(u)
+
+	case u.HasAction(view.ActionSaveTemplate) || u.HasChain(view.ActionSaveTemplate) && !u.IsButton():
+		b.HandleSaveTemplate(u)
+
+	case u.HasAction(view.ActionShowTemplates) || u.HasAction(view.ActionUseTemplate) || u.HasAction(view.ActionDeleteTemplate) ||
+		u.HasChain(view.ActionUseTemplate) && !u.IsButton():
+		b.HandleTemplates(u)
+
+	case u.HasAction(view.ActionExportRoom):
+		b.HandleExportRoom(u)
+
+	case u.HasAction(view.ActionOpenBoard):
+		b.HandleOpenBoard(u)
+
+	case u.HasAction(view.ActionPublishBatch):
+		b.HandlePublishBatch(u)
+
+	case u.HasAction(view.ActionAddBallotRate):
+		b.HandleBallotRate(u)
+
 	case u.HasAction(view.ActionShowTasks):
 		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermViewRoom, roomId) {
+			return
+		}
 		page, _ := strconv.Atoi(u.GetButton().GetData("page"))
 		_, _ = b.view.ShowTasks(roomId, page, u)
 
-	case u.HasAction(view.ActionJoinRoom):
+	case u.HasAction(view.ActionJoinRoom) || u.HasAction(view.ActionObserveRoom):
 		roomId := u.GetButton().GetData("roomId")
-		if err := b.roomService.SaveRoomMember(u.GetUser().UserId, roomId); err != nil {
+		role := model.RoleVoter
+		if u.HasAction(view.ActionObserveRoom) {
+			role = model.RoleObserver
+		}
+		previous, err := b.roomService.GetMember(u.GetUser().UserId, roomId)
+		if err != nil {
+			log.Printf("[ERROR] %v", err)
+			b.sendErrorMessage(u)
+			return
+		}
+		if err = b.roomService.SaveRoomMember(u.GetUser().UserId, roomId, role); err != nil {
 			log.Printf("[ERROR] %v", err)
 			b.sendErrorMessage(u)
 			return
 		}
 		_, _ = b.view.ShowRoomViewInline(roomId, u)
+		if role == model.RoleVoter && (previous == nil || previous.IsObserver()) {
+			b.sendOpenBallots(u.GetUser().UserId, roomId)
+		}
 
 	case u.HasAction(view.ActionSetGroupOfRoom):
 		roomId := u.GetButton().GetData("roomId")
 		chatId := u.GetButton().GetData("chatId")
 		chatIdInt64, _ := strconv.ParseInt(chatId, 10, 64)
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
 
 		_, err := b.view.SendChatWritingAction(chatIdInt64)
 		if err != nil {
@@ -146,23 +316,11 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 
 	case u.HasAction(view.ActionFinishTask):
 		roomId := u.GetButton().GetData("roomId")
-		room, err := b.roomService.GetRoomById(roomId)
-		if err != nil {
-			log.Printf("[ERROR] unable to get room by roomId: %d, %v", roomId, err)
-			return
-		}
-		if room.UserId != u.GetUserId() {
-			log.Printf("[WARN] not finished by not admin user: %d, %v", u.GetUserId(), err)
-			_, _ = b.view.ErrorMessage(u, "❗️ Раскрыться может только администратор комнаты")
+		if !b.authorize(u, service.PermReveal, roomId) {
 			return
 		}
 
 		taskId := u.GetButton().GetData("taskId")
-		if err = b.taskService.SetFinished(taskId); err != nil {
-			log.Printf("[ERROR] unable to set room by roomId: %d, %v", roomId, err)
-			return
-		}
-
 		rates, err := b.rateService.GetRatesByTaskId(taskId)
 		if err != nil {
 			log.Printf("[ERROR] some less important message, %v", err)
@@ -173,35 +331,22 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 			return
 		}
 
-		err = b.taskService.SetFinished(taskId)
-		if err != nil {
-			log.Printf("[ERROR]  %v", err)
-			return
-		}
-		_, _ = b.view.ShowFinishedTaskView(taskId, roomId, rates, u)
-		_, _ = b.view.ShowSetTaskGrade(taskId, roomId, u)
+		b.revealTask(taskId, roomId, u)
 
 	case u.HasAction(view.ActionFinishRoom):
 		roomId := u.GetButton().GetData("roomId")
-		room, err := b.roomService.GetRoomById(roomId)
-		if err != nil {
-			log.Printf("[ERROR] unable to get room by roomId: %d, %v", roomId, err)
+		if !b.authorize(u, service.PermFinishRoom, roomId) {
 			return
 		}
-		if room.Status == model.Finished {
+		_, err := b.roomService.FinishRoom(roomId, u.GetUserId())
+		switch {
+		case err == service.ErrRoomFinished:
 			_, _ = b.view.ErrorMessage(u, "❗️ Планирование уже завершено")
-			return
-		}
-
-		_, err = b.view.ShowTasksAfterFinishedRoom(roomId, u)
-		if err == nil {
-			if err = b.roomService.SetStatusRoom(model.Finished, roomId); err != nil {
-				log.Printf("[ERROR] unable to set finished for room: %d, %v", roomId, err)
-				return
-			}
-			_, _ = b.view.ErrorMessage(u, "Планирование успешно завершено")
-		} else {
+		case err != nil:
+			log.Printf("[ERROR] unable to finish room: %v, %v", roomId, err)
 			_, _ = b.view.ErrorMessage(u, "Не удалось завершить планирование")
+		default:
+			_, _ = b.view.ErrorMessage(u, "Планирование успешно завершено")
 		}
 
 	case u.HasActionOrChain(view.ActionFinishTaskRate):
@@ -209,6 +354,9 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 
 	case u.HasAction(view.ActionNextTask):
 		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermNextTask, roomId) {
+			return
+		}
 		task, err := b.taskService.GetNextNotFinishedTask(roomId)
 		if err != nil {
 			log.Printf("[ERROR]  %v", err)
@@ -220,10 +368,6 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 			log.Printf("[ERROR] unable to get room by roomId: %d, %v", roomId, err)
 			return
 		}
-		if room.UserId != u.GetUserId() {
-			_, _ = b.view.ErrorMessage(u, "")
-			return
-		}
 		b.postTask(u, room.ChatId, task.Id.String(), roomId)
 	}
 
@@ -243,7 +387,17 @@ func (b *BotApp) Handle(u *tgbot.Update) {
 }
 
 func (b *BotApp) postTask(u *tgbot.Update, chatId int64, taskId, roomId string) {
-	msg, err := b.view.ShowTaskView(chatId, taskId, roomId, u)
+	room, err := b.roomService.GetRoomById(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return
+	}
+	if chatId == 0 {
+		_, _ = b.view.ErrorMessage(u, "❗️ Комната не привязана к чату, привяжите ее в разделе Управление")
+		return
+	}
+	room.ChatId = chatId
+	msg, err := b.publishTask(room, taskId)
 	if err != nil {
 		_, _ = b.view.ErrorMessage(u, "❗️ Не получилось опубликовать задачу")
 	} else {
@@ -253,3 +407,57 @@ func (b *BotApp) postTask(u *tgbot.Update, chatId int64, taskId, roomId string)
 		_, _ = b.view.ShowRoomView(messageLink, roomId, u)
 	}
 }
+
+// publishTask posts the vote message to the room chat, remembers where it lives and starts the countdown.
+func (b *BotApp) publishTask(room model.Room, taskId string) (tgbotapi.Message, error) {
+	msg, err := b.view.ShowTaskView(room.ChatId, taskId, room.Id.String(), nil)
+	if err != nil {
+		return msg, err
+	}
+	if err = b.taskService.SetPublished(taskId, room.ChatId, msg.MessageID); err != nil {
+		log.Printf("[ERROR] unable to set published task: %v, %v", taskId, err)
+		return msg, nil
+	}
+	if room.HasTimer() {
+		b.startTaskTimer(taskId, room.Id.String(), msg.MessageID)
+	}
+	return msg, nil
+}
+
+// HandleEvent is the event listener of the bot, it tells the chats about changes whether they come from the bot or the API.
+func (b *BotApp) HandleEvent(event model.Event) {
+	if event.Type == model.EventRoomFinished {
+		_, _ = b.view.ShowTasksAfterFinishedRoom(event.RoomId)
+		_, _ = b.view.ShowRoomFinished(event.RoomId)
+	}
+}
+
+// revealTask finishes the current round and shows the results. u is set only when a facilitator reveals the task,
+// it is nil for reveals by votes or timers.
+func (b *BotApp) revealTask(taskId, roomId string, u *tgbot.Update) {
+	rates, err := b.rateService.GetRatesByTaskId(taskId)
+	if err != nil {
+		log.Printf("[ERROR] unable to GetRatesByTaskId for taskId %v, %v", taskId, err)
+		return
+	}
+	revealed, err := b.taskService.SetFinished(taskId)
+	if err != nil {
+		log.Printf("[ERROR] unable to set finished task: %v, %v", taskId, err)
+		return
+	}
+	// the timer, the last vote and a facilitator may reveal the task at once, only the first one shows the results
+	if !revealed {
+		if u != nil {
+			_, _ = b.view.ErrorMessage(u, "❗️ Голосование по задаче уже завершено")
+		}
+		return
+	}
+	_, _ = b.view.ShowFinishedTaskView(taskId, roomId, rates, u)
+
+	// the grade is asked from the facilitator who revealed the task, reveals by votes or timers go to the owner
+	var grader int64
+	if u != nil && b.access.Check(service.PermGrade, u.GetUserId(), roomId) == nil {
+		grader = u.GetUserId()
+	}
+	_, _ = b.view.ShowSetTaskGrade(taskId, roomId, grader)
+}
@@ -0,0 +1,61 @@
+package bot_handler
+
+import (
+	log "github.com/go-pkgz/lgr"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+)
+
+// HandleBotChat keeps room chats valid: rooms are unbound when the bot is removed from their chat
+// and follow the group when it is upgraded to a supergroup with a new chat id.
+func (b *BotApp) HandleBotChat(u *tgbot.Update) {
+	chatId := u.GetChatId()
+
+	if newChatId := u.GetMigrateToChatId(); newChatId != 0 {
+		rooms, err := b.roomService.MigrateChatRooms(chatId, newChatId)
+		if err != nil {
+			log.Printf("[ERROR] unable to migrate rooms from chat %v to %v, %v", chatId, newChatId, err)
+			return
+		}
+		log.Printf("[INFO] chat %v migrated to %v, rooms moved: %d", chatId, newChatId, len(rooms))
+		for _, room := range rooms {
+			b.republishCurrentTask(room)
+		}
+		return
+	}
+
+	if !u.IsBotRemoved() || u.MyChatMember.Chat.IsPrivate() {
+		return
+	}
+	rooms, err := b.roomService.UnbindChatRooms(chatId)
+	if err != nil {
+		log.Printf("[ERROR] unable to unbind rooms of chat %v, %v", chatId, err)
+		return
+	}
+	for _, room := range rooms {
+		log.Printf("[INFO] bot removed from chat %v, room %v unbound", chatId, room.Id)
+		// timers would edit a message of the chat the bot is not in anymore, batch deadlines keep running
+		// since ballots live in private chats and the grade is asked from the owner
+		if _, err = b.scheduler.CancelRoomJobs(room.Id.String(), JobTaskTimer); err != nil {
+			log.Printf("[ERROR] unable to cancel jobs of room %v, %v", room.Id, err)
+		}
+		_, _ = b.view.ShowChatLost(room, u.MyChatMember.Chat.Title)
+	}
+}
+
+// republishCurrentTask posts the task being voted in the room again after the chat migrated, the vote message
+// of the old group cannot be edited, so the new message also restarts the countdown.
+func (b *BotApp) republishCurrentTask(room model.Room) {
+	roomId := room.Id.String()
+	task, err := b.taskService.GetCurrentTask(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get current task, roomId: %v, %v", roomId, err)
+		return
+	}
+	if task == nil || task.Finished || task.BatchId.Valid || task.ChatId != room.ChatId {
+		return
+	}
+	if _, err = b.publishTask(room, task.Id.String()); err != nil {
+		log.Printf("[ERROR] unable to publish task %v in migrated chat %v, %v", task.Id, room.ChatId, err)
+	}
+}
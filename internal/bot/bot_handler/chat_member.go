@@ -0,0 +1,91 @@
+package bot_handler
+
+import (
+	"fmt"
+	log "github.com/go-pkgz/lgr"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+)
+
+// HandleChatMembers keeps the members of rooms bound to the chat in sync with the group: people leaving the group
+// leave its rooms, people joining it join the rooms with auto-join enabled.
+func (b *BotApp) HandleChatMembers(u *tgbot.Update) {
+	chatId := u.GetChatId()
+
+	if left := u.GetLeftMember(); left != nil && !left.IsBot {
+		rooms, err := b.roomService.LeaveChatRooms(chatId, left.ID)
+		if err != nil {
+			log.Printf("[ERROR] unable to remove user %v who left chat %v from rooms, %v", left.ID, chatId, err)
+		}
+		for _, room := range rooms {
+			_, _ = b.view.ShowRoomNotice(chatId, fmt.Sprintf("👋 *%v* покинул чат и больше не участвует в комнате *%v*",
+				escape(left.FirstName), escape(room.Name)))
+			b.recheckVotes(room)
+		}
+	}
+
+	for _, joined := range u.GetJoinedMembers() {
+		if joined.IsBot {
+			continue
+		}
+		user := tgbot.User{UserId: joined.ID, UserName: joined.UserName, DisplayName: joined.FirstName}
+		if err := b.roomService.SaveUser(user); err != nil {
+			log.Printf("[ERROR] unable to save user %v who joined chat %v, %v", joined.ID, chatId, err)
+			continue
+		}
+		rooms, err := b.roomService.JoinChatRooms(chatId, joined.ID)
+		if err != nil {
+			log.Printf("[ERROR] unable to add user %v who joined chat %v to rooms, %v", joined.ID, chatId, err)
+		}
+		for _, room := range rooms {
+			_, _ = b.view.ShowRoomNotice(chatId, fmt.Sprintf("👋 *%v* присоединился к комнате *%v*",
+				escape(joined.FirstName), escape(room.Name)))
+			b.sendOpenBallots(joined.ID, room.Id.String())
+		}
+	}
+}
+
+// autoJoin adds the user voting on a published task to the room when the room has auto-join enabled.
+func (b *BotApp) autoJoin(room model.Room, u *tgbot.Update) {
+	joined, err := b.roomService.AutoJoinRoom(room, u.GetUserId())
+	if err != nil {
+		log.Printf("[ERROR] unable to auto-join user %v to room: %v, %v", u.GetUserId(), room.Id, err)
+		return
+	}
+	if joined {
+		log.Printf("[INFO] user %v auto-joined room %v", u.GetUserId(), room.Id)
+	}
+}
+
+// recheckVotes reveals what was waiting only for the votes of members who left: the current task of a live room
+// or the open batches of an async room.
+func (b *BotApp) recheckVotes(room model.Room) {
+	roomId := room.Id.String()
+	if room.IsAsync() {
+		batches, err := b.taskService.GetOpenBatches(roomId)
+		if err != nil {
+			log.Printf("[ERROR] unable to get open batches, roomId: %v, %v", roomId, err)
+			return
+		}
+		for _, batch := range batches {
+			finished, err := b.taskService.BatchFinished(batch.Id.String())
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+				continue
+			}
+			if finished {
+				b.revealBatch(batch.Id.String(), roomId)
+			}
+		}
+		return
+	}
+
+	task, err := b.taskService.GetCurrentTask(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get current task, roomId: %v, %v", roomId, err)
+		return
+	}
+	if task != nil && !task.Finished {
+		b.checkVotes(task.Id.String(), roomId)
+	}
+}
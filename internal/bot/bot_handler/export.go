@@ -0,0 +1,48 @@
+package bot_handler
+
+import (
+	"fmt"
+	log "github.com/go-pkgz/lgr"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gotestbot/internal/export"
+	"gotestbot/internal/service"
+	"gotestbot/sdk/tgbot"
+)
+
+// HandleExportRoom offers the export formats, and sends the results file to the user once a format is chosen.
+func (b *BotApp) HandleExportRoom(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermViewRoom, roomId) {
+		return
+	}
+	if u.GetButton().GetData("format") == "" {
+		_, _ = b.view.ShowExportFormats(roomId, u)
+		return
+	}
+
+	format, err := export.ParseFormat(u.GetButton().GetData("format"))
+	if err != nil {
+		log.Printf("[WARN] %v", err)
+		b.sendErrorMessage(u)
+		return
+	}
+	room, err := b.roomService.GetRoomById(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	fileName, data, err := b.exporter.Export(roomId, format)
+	if err != nil {
+		log.Printf("[ERROR] unable to export room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+
+	caption := fmt.Sprintf("📊 Результаты комнаты *%v*", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name))
+	if _, err = b.view.SendDocument(u.GetUserId(), fileName, data, caption); err != nil {
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.WarnMessage("Файл отправлен", u)
+}
@@ -0,0 +1,99 @@
+package bot_handler
+
+import (
+	"fmt"
+	"github.com/go-pkgz/lgr"
+	"github.com/pkg/errors"
+	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/importer"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+)
+
+// HandleImportTasks imports tasks from a tracker export sent as a document. The file is parsed once for the preview
+// and once more when the room is chosen, so only its id is kept in the chain.
+func (b *BotApp) HandleImportTasks(u *tgbot.Update) {
+
+	if u.HasDocument() {
+		format, drafts, ok := b.parseDocument(u)
+		if !ok {
+			return
+		}
+		rooms, err := b.roomService.GetManagedRoomsByUserId(u.GetUserId())
+		if err != nil {
+			lgr.Printf("[ERROR] unable to get rooms of user: %v, %v", u.GetUserId(), err)
+			b.sendErrorMessage(u)
+			return
+		}
+
+		document := u.GetDocument()
+		u.FinishChain().
+			StartChain(string(view.ActionImportTasks)).
+			StartChainStep("ROOM").
+			AddChainData("fileId", document.FileID).
+			AddChainData("fileName", document.FileName).
+			FlushChatInfo()
+		_, _ = b.view.ShowImportPreview(format, drafts, rooms, u)
+		return
+	}
+
+	if !u.HasAction(view.ActionImportTasks) || u.GetChainStep() != "ROOM" {
+		return
+	}
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermAddTask, roomId) {
+		return
+	}
+	room, err := b.roomService.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return
+	}
+	if room.Status == model.Finished {
+		_, _ = b.view.ErrorMessage(u, "❗️ Планирование уже завершено")
+		return
+	}
+
+	data, err := b.view.DownloadFile(u.GetChainData("fileId"))
+	if err != nil {
+		b.sendErrorMessage(u)
+		return
+	}
+	_, drafts, err := importer.Import(u.GetChainData("fileName"), data)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to import file: %v, %v", u.GetChainData("fileName"), err)
+		b.sendErrorMessage(u)
+		return
+	}
+	if err = b.taskService.SaveDrafts(roomId, drafts); err != nil {
+		lgr.Printf("[ERROR] unable to save imported tasks of room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	u.FinishChain().FlushChatInfo()
+	_, _ = b.view.ShowRoomView(fmt.Sprintf("📥 Импортировано задач: %d\n\n", len(drafts)), roomId, u)
+}
+
+func (b *BotApp) parseDocument(u *tgbot.Update) (string, []model.TaskDraft, bool) {
+	data, err := b.view.DownloadFile(u.GetDocument().FileID)
+	if err != nil {
+		_, _ = b.view.ErrorMessageText("❗️ Не удалось скачать файл, размер файла не должен превышать 5 МБ", u)
+		return "", nil, false
+	}
+
+	format, drafts, err := importer.Import(u.GetDocument().FileName, data)
+	switch {
+	case errors.Is(err, importer.ErrUnknownFormat):
+		_, _ = b.view.ErrorMessageText("❗️ Неизвестный формат файла. Поддерживаются выгрузки Jira CSV, GitHub issues JSON и Trello JSON", u)
+		return "", nil, false
+	case err != nil:
+		lgr.Printf("[WARN] unable to parse document %v, %v", u.GetDocument().FileName, err)
+		_, _ = b.view.ErrorMessageText(fmt.Sprintf("❗️ Не удалось разобрать файл %v", format), u)
+		return "", nil, false
+	case len(drafts) == 0:
+		_, _ = b.view.ErrorMessageText(fmt.Sprintf("❗️ В файле %v не найдено задач", format), u)
+		return "", nil, false
+	}
+	return format, drafts, true
+}
@@ -0,0 +1,289 @@
+package bot_handler
+
+import (
+	"fmt"
+	log "github.com/go-pkgz/lgr"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+	"strconv"
+	"strings"
+)
+
+// HandleRoomAdmin serves the owner's screen of the room. Every change is confirmed first and announced in the room chat.
+func (b *BotApp) HandleRoomAdmin(u *tgbot.Update) {
+	switch {
+	case u.HasAction(view.ActionShowRoomAdmin):
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermAdminRoom, roomId) {
+			return
+		}
+		if u.HasChain(view.ActionRenameRoom) || u.HasChain(view.ActionRebindChat) {
+			u.FinishChain().FlushChatInfo()
+		}
+		_, _ = b.view.ShowRoomAdmin("", roomId, u)
+
+	case u.HasAction(view.ActionRenameRoom) || u.HasChain(view.ActionRenameRoom) && !u.IsButton():
+		b.renameRoom(u)
+
+	case u.HasAction(view.ActionRebindChat) || u.HasChain(view.ActionRebindChat) && !u.IsButton():
+		b.rebindChat(u)
+
+	case u.HasAction(view.ActionRemoveMember):
+		b.removeMember(u)
+
+	case u.HasAction(view.ActionTransferRoom):
+		b.transferRoom(u)
+
+	case u.HasAction(view.ActionSetFacilitator):
+		b.setFacilitator(u)
+	}
+}
+
+func (b *BotApp) renameRoom(u *tgbot.Update) {
+	if !u.IsButton() {
+		if u.GetChainStep() != "NAME" {
+			return
+		}
+		name := strings.TrimSpace(u.GetText())
+		if name == "" {
+			_, _ = b.view.ErrorMessageText("❗️ Название не может быть пустым, попробуйте еще раз", u)
+			return
+		}
+		roomId := u.GetChainData("roomId")
+		u.FinishChain().FlushChatInfo()
+		room, ok := b.adminRoom(roomId, u)
+		if !ok {
+			return
+		}
+		text := fmt.Sprintf("Переименовать комнату *%v* в *%v*?", escape(room.Name), escape(name))
+		_, _ = b.view.ConfirmRoomAdmin(text, view.ActionRenameRoom, map[string]string{"roomId": roomId, "name": name}, u)
+		return
+	}
+
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermAdminRoom, roomId) {
+		return
+	}
+	room, ok := b.adminRoom(roomId, u)
+	if !ok {
+		return
+	}
+	if u.GetButton().GetData("confirm") == "" {
+		u.StartChain(string(view.ActionRenameRoom)).
+			StartChainStep("NAME").
+			AddChainData("roomId", roomId).
+			FlushChatInfo()
+		_, _ = b.view.AddRoomNewName(room, u)
+		return
+	}
+
+	name := u.GetButton().GetData("name")
+	if err := b.roomService.SetNameRoom(roomId, name); err != nil {
+		log.Printf("[ERROR] unable to set name for room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowRoomNotice(room.ChatId, fmt.Sprintf("✏️ Комната *%v* переименована в *%v*", escape(room.Name), escape(name)))
+	_, _ = b.view.ShowRoomAdmin("✅ Комната переименована\n\n", roomId, u)
+}
+
+func (b *BotApp) rebindChat(u *tgbot.Update) {
+	if !u.IsButton() {
+		sentId, ok := sentRoomId(u)
+		if !ok || u.GetChainStep() != "CHAT" {
+			return
+		}
+		roomId := u.GetChainData("roomId")
+		if sentId != roomId {
+			return
+		}
+		u.FinishChain().FlushChatInfo()
+		room, ok := b.adminRoom(roomId, u)
+		if !ok {
+			return
+		}
+		chat := u.Update.Message.Chat
+		if room.ChatId == chat.ID {
+			_, _ = b.view.ShowRoomAdmin("❗️ Комната уже привязана к этому чату\n\n", roomId, u)
+			return
+		}
+		text := fmt.Sprintf("Привязать комнату *%v* к чату *%v*?", escape(room.Name), escape(chat.Title))
+		_, _ = b.view.ConfirmRoomAdmin(text, view.ActionRebindChat, map[string]string{
+			"roomId":   roomId,
+			"chatId":   strconv.FormatInt(chat.ID, 10),
+			"chatName": chat.Title}, u)
+		return
+	}
+
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermAdminRoom, roomId) {
+		return
+	}
+	room, ok := b.adminRoom(roomId, u)
+	if !ok {
+		return
+	}
+	if u.GetButton().GetData("confirm") == "" {
+		u.StartChain(string(view.ActionRebindChat)).
+			StartChainStep("CHAT").
+			AddChainData("roomId", roomId).
+			FlushChatInfo()
+		_, _ = b.view.AddRoomNewChat(room, u)
+		return
+	}
+
+	chatName := u.GetButton().GetData("chatName")
+	chatId, _ := strconv.ParseInt(u.GetButton().GetData("chatId"), 10, 64)
+	if _, err := b.view.SendChatWritingAction(chatId); err != nil {
+		log.Printf("[ERROR] bot is not in chat %v, %v", chatId, err)
+		_, _ = b.view.ErrorMessage(u, fmt.Sprintf("❗Сперва добавьте бота в чат %v", chatName))
+		return
+	}
+	if err := b.roomService.SetChatIdRoom(roomId, chatId); err != nil {
+		log.Printf("[ERROR] unable to set chat for room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowRoomNotice(room.ChatId, fmt.Sprintf("🔗 Комната *%v* переехала в чат *%v*", escape(room.Name), escape(chatName)))
+	_, _ = b.view.ShowRoomNotice(chatId, fmt.Sprintf("🔗 Комната *%v* теперь привязана к этому чату", escape(room.Name)))
+	_, _ = b.view.ShowRoomAdmin(fmt.Sprintf("✅ Чат %v успешно привязан\n\n", escape(chatName)), roomId, u)
+}
+
+func (b *BotApp) removeMember(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermAdminRoom, roomId) {
+		return
+	}
+	if u.GetButton().GetData("userId") == "" {
+		_, _ = b.view.ShowAdminMembers("👤 Кого удалить из комнаты?", view.ActionRemoveMember, roomId, u)
+		return
+	}
+	room, member, ok := b.adminMember(roomId, u)
+	if !ok {
+		return
+	}
+	if u.GetButton().GetData("confirm") == "" {
+		text := fmt.Sprintf("Удалить *%v* из комнаты *%v*? Голоса участника по незавершенным задачам тоже будут удалены",
+			escape(member.DisplayName), escape(room.Name))
+		_, _ = b.view.ConfirmRoomAdmin(text, view.ActionRemoveMember, map[string]string{
+			"roomId": roomId,
+			"userId": strconv.FormatInt(member.UserId, 10)}, u)
+		return
+	}
+
+	if err := b.roomService.DeleteRoomMember(member.UserId, roomId); err != nil {
+		log.Printf("[ERROR] unable to delete member %v from room: %v, %v", member.UserId, roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowRoomNotice(room.ChatId, fmt.Sprintf("👋 *%v* больше не участвует в комнате *%v*", escape(member.DisplayName), escape(room.Name)))
+	_, _ = b.view.ShowRoomAdmin("✅ Участник удален\n\n", roomId, u)
+	b.recheckVotes(room)
+}
+
+func (b *BotApp) transferRoom(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermAdminRoom, roomId) {
+		return
+	}
+	if u.GetButton().GetData("userId") == "" {
+		_, _ = b.view.ShowAdminMembers("👑 Кому передать комнату?", view.ActionTransferRoom, roomId, u)
+		return
+	}
+	room, member, ok := b.adminMember(roomId, u)
+	if !ok {
+		return
+	}
+	if u.GetButton().GetData("confirm") == "" {
+		text := fmt.Sprintf("Передать комнату *%v* участнику *%v*? Вы останетесь ведущим, но управлять комнатой сможет только новый владелец",
+			escape(room.Name), escape(member.DisplayName))
+		_, _ = b.view.ConfirmRoomAdmin(text, view.ActionTransferRoom, map[string]string{
+			"roomId": roomId,
+			"userId": strconv.FormatInt(member.UserId, 10)}, u)
+		return
+	}
+
+	if err := b.roomService.SetOwnerRoom(roomId, member.UserId); err != nil {
+		log.Printf("[ERROR] unable to set owner %v for room: %v, %v", member.UserId, roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowRoomNotice(room.ChatId, fmt.Sprintf("👑 Владельцем комнаты *%v* стал *%v*", escape(room.Name), escape(member.DisplayName)))
+	_, _ = b.view.ShowRoomView("✅ Комната передана\n\n", roomId, u)
+}
+
+// setFacilitator promotes a member to a co-facilitator with the same rights as the owner, or demotes a co-facilitator to a voter.
+func (b *BotApp) setFacilitator(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermAdminRoom, roomId) {
+		return
+	}
+	if u.GetButton().GetData("userId") == "" {
+		_, _ = b.view.ShowFacilitators(roomId, u)
+		return
+	}
+	room, member, ok := b.adminMember(roomId, u)
+	if !ok {
+		return
+	}
+	promote := member.Role != model.RoleFacilitator
+	if u.GetButton().GetData("confirm") == "" {
+		text := fmt.Sprintf("Назначить *%v* ведущим комнаты *%v*?", escape(member.DisplayName), escape(room.Name))
+		if !promote {
+			text = fmt.Sprintf("Снять с *%v* роль ведущего комнаты *%v*?", escape(member.DisplayName), escape(room.Name))
+		}
+		_, _ = b.view.ConfirmRoomAdmin(text, view.ActionSetFacilitator, map[string]string{
+			"roomId": roomId,
+			"userId": strconv.FormatInt(member.UserId, 10)}, u)
+		return
+	}
+
+	role, notice := model.RoleFacilitator, "⭐️ *%v* теперь ведущий комнаты *%v*"
+	if !promote {
+		role, notice = model.RoleVoter, "*%v* больше не ведущий комнаты *%v*"
+	}
+	if err := b.roomService.SetRoleRoomMember(member.UserId, roomId, role); err != nil {
+		log.Printf("[ERROR] unable to set role %v for member %v of room: %v, %v", role, member.UserId, roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowRoomNotice(room.ChatId, fmt.Sprintf(notice, escape(member.DisplayName), escape(room.Name)))
+	_, _ = b.view.ShowFacilitators(roomId, u)
+}
+
+func (b *BotApp) adminRoom(roomId string, u *tgbot.Update) (model.Room, bool) {
+	room, err := b.roomService.GetRoomById(roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return room, false
+	}
+	return room, true
+}
+
+// adminMember loads the room and the member picked on the admin screen, the owner cannot be picked.
+func (b *BotApp) adminMember(roomId string, u *tgbot.Update) (model.Room, model.Member, bool) {
+	room, ok := b.adminRoom(roomId, u)
+	if !ok {
+		return room, model.Member{}, false
+	}
+	userId, _ := strconv.ParseInt(u.GetButton().GetData("userId"), 10, 64)
+	member, err := b.roomService.GetMember(userId, roomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get member %v of room: %v, %v", userId, roomId, err)
+		b.sendErrorMessage(u)
+		return room, model.Member{}, false
+	}
+	if member == nil || member.UserId == room.UserId {
+		_, _ = b.view.ErrorMessage(u, "❗️ Участник уже не состоит в комнате")
+		return room, model.Member{}, false
+	}
+	return room, *member, true
+}
+
+func escape(text string) string {
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text)
+}
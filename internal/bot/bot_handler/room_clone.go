@@ -0,0 +1,181 @@
+package bot_handler
+
+import (
+	"database/sql"
+	"fmt"
+	log "github.com/go-pkgz/lgr"
+	"github.com/pkg/errors"
+	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
+	"gotestbot/sdk/tgbot"
+	"strings"
+)
+
+// HandleCloneRoom creates a copy of the room: the name is asked first,
+// then whether unfinished tasks move too, unless the room has none.
+func (b *BotApp) HandleCloneRoom(u *tgbot.Update) {
+	switch {
+	case u.HasAction(view.ActionCloneRoom) && u.GetButton().GetData("tasks") == "":
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+		room, err := b.roomService.GetRoomById(roomId)
+		if err != nil {
+			log.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		u.StartChain(string(view.ActionCloneRoom)).StartChainStep("NAME").
+			AddChainData("roomId", roomId).FlushChatInfo()
+		_, _ = b.view.ErrorMessageText(fmt.Sprintf("🧬 Введите название новой комнаты. Чат, участники и настройки "+
+			"будут скопированы из комнаты *%v*", room.Name), u)
+
+	case u.HasChain(view.ActionCloneRoom) && u.GetChainStep() == "NAME":
+		name := strings.TrimSpace(u.GetText())
+		if name == "" {
+			return
+		}
+		roomId := u.GetChainData("roomId")
+		_, err := b.taskService.GetNextNotFinishedTask(roomId)
+		if errors.Cause(err) == sql.ErrNoRows {
+			u.FinishChain().FlushChatInfo()
+			b.cloneRoom(roomId, name, false, u)
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] unable to get unfinished tasks, roomId: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		u.StartChainStep("TASKS").AddChainData("name", name).FlushChatInfo()
+		_, _ = b.view.AddCloneTasks(roomId, u)
+
+	case u.HasAction(view.ActionCloneRoom) && u.HasChain(view.ActionCloneRoom) && u.GetChainStep() == "TASKS":
+		roomId, name := u.GetChainData("roomId"), u.GetChainData("name")
+		u.FinishChain().FlushChatInfo()
+		b.cloneRoom(roomId, name, u.GetButton().GetData("tasks") == "yes", u)
+
+	case u.IsButton():
+		_, _ = b.view.ErrorMessage(u, "❗️ Клонирование уже завершено")
+	}
+}
+
+func (b *BotApp) cloneRoom(sourceRoomId, name string, withTasks bool, u *tgbot.Update) {
+	if !b.authorize(u, service.PermManageRoom, sourceRoomId) {
+		return
+	}
+	source, err := b.roomService.GetRoomById(sourceRoomId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get room by roomId: %v, %v", sourceRoomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	room, err := b.roomService.CloneRoom(sourceRoomId, name, u.GetUserId(), withTasks)
+	if err != nil {
+		log.Printf("[ERROR] unable to clone room: %v, %v", sourceRoomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	if room.ChatId != 0 {
+		_, _ = b.view.ShowRoomCloned(room, source.Name)
+	}
+	_, _ = b.view.ShowRoomView("🧬 Комната скопирована\n\n", room.Id.String(), u)
+}
+
+// HandleSaveTemplate saves the room as a named template of the user.
+func (b *BotApp) HandleSaveTemplate(u *tgbot.Update) {
+	if u.HasAction(view.ActionSaveTemplate) {
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+		u.StartChain(string(view.ActionSaveTemplate)).StartChainStep("NAME").
+			AddChainData("roomId", roomId).FlushChatInfo()
+		_, _ = b.view.ErrorMessageText("💾 Введите название шаблона", u)
+		return
+	}
+
+	name := strings.TrimSpace(u.GetText())
+	if name == "" {
+		return
+	}
+	roomId := u.GetChainData("roomId")
+	u.FinishChain().FlushChatInfo()
+	if _, err := b.roomService.SaveRoomAsTemplate(roomId, name, u.GetUserId()); err != nil {
+		log.Printf("[ERROR] unable to save template of room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowRoomView(fmt.Sprintf("💾 Шаблон *%v* сохранен\n\n", name), roomId, u)
+}
+
+// HandleTemplates lists templates of the user, deletes them and starts rooms from them.
+func (b *BotApp) HandleTemplates(u *tgbot.Update) {
+	switch {
+	case u.HasAction(view.ActionShowTemplates):
+		b.showTemplates("", u)
+
+	case u.HasAction(view.ActionDeleteTemplate):
+		templateId := u.GetButton().GetData("templateId")
+		if !b.ownTemplate(templateId, u) {
+			return
+		}
+		if err := b.roomService.DeleteTemplate(templateId); err != nil {
+			log.Printf("[ERROR] unable to delete template: %v, %v", templateId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		b.showTemplates("🗑 Шаблон удален\n\n", u)
+
+	case u.HasAction(view.ActionUseTemplate):
+		templateId := u.GetButton().GetData("templateId")
+		if !b.ownTemplate(templateId, u) {
+			return
+		}
+		u.StartChain(string(view.ActionUseTemplate)).StartChainStep("NAME").
+			AddChainData("templateId", templateId).FlushChatInfo()
+		_, _ = b.view.ErrorMessageText("Введите название комнаты", u)
+
+	default:
+		name := strings.TrimSpace(u.GetText())
+		if name == "" {
+			return
+		}
+		templateId := u.GetChainData("templateId")
+		u.FinishChain().FlushChatInfo()
+		room, err := b.roomService.StartRoomFromTemplate(templateId, name, u.GetUserId())
+		if err != nil {
+			log.Printf("[ERROR] unable to start room from template: %v, %v", templateId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		_, _ = b.view.ShowRoomView("📑 Комната создана из шаблона\n\n", room.Id.String(), u)
+	}
+}
+
+func (b *BotApp) showTemplates(prefix string, u *tgbot.Update) {
+	templates, err := b.roomService.GetTemplatesByUserId(u.GetUserId())
+	if err != nil {
+		log.Printf("[ERROR] unable to get templates of user: %v, %v", u.GetUserId(), err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowTemplates(prefix, templates, u)
+}
+
+// ownTemplate checks that the template belongs to the user, templates are private.
+func (b *BotApp) ownTemplate(templateId string, u *tgbot.Update) bool {
+	template, err := b.roomService.GetTemplateById(templateId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get template: %v, %v", templateId, err)
+		b.sendErrorMessage(u)
+		return false
+	}
+	if template.UserId != u.GetUserId() {
+		log.Printf("[WARN] audit: user %d tried to use template %v of user %d", u.GetUserId(), templateId, template.UserId)
+		_, _ = b.view.ErrorMessage(u, "❗️ Это чужой шаблон")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package bot_handler
+
+import (
+	"github.com/go-pkgz/lgr"
+	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+	"strconv"
+	"strings"
+)
+
+func (b *BotApp) HandleSetRevealPolicy(u *tgbot.Update) {
+
+	if u.HasAction(view.ActionSetRevealPolicy) {
+		roomId := u.GetButton().GetData("roomId")
+		policy := model.RevealPolicy(u.GetButton().GetData("policy"))
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+
+		if policy == model.RevealAll {
+			b.setRevealPolicy(roomId, policy, 0, u)
+			return
+		}
+
+		u.StartChain(string(view.ActionSetRevealPolicy)).
+			StartChainStep("QUORUM").
+			AddChainData("roomId", roomId).
+			AddChainData("policy", string(policy)).
+			FlushChatInfo()
+		_, _ = b.view.AddRevealQuorum(policy, u)
+		return
+	}
+
+	switch u.GetChainStep() {
+	case "QUORUM":
+		policy := model.RevealPolicy(u.GetChainData("policy"))
+		quorum, err := strconv.Atoi(strings.TrimSpace(u.GetText()))
+		if err != nil || quorum < 1 || policy == model.RevealPercent && quorum > 100 ||
+			policy == model.RevealPresent && quorum > view.MaxPresentHours {
+			_, _ = b.view.ErrorMessageText("❗️ Некорректное значение, попробуйте еще раз", u)
+			return
+		}
+		roomId := u.GetChainData("roomId")
+		u.FinishChain().FlushChatInfo()
+		if policy == model.RevealPresent {
+			// the presence window is kept apart from the quorum, the policy itself has no quorum
+			if err = b.roomService.SetPresentHoursRoom(roomId, quorum); err != nil {
+				lgr.Printf("[ERROR] unable to set present hours for room: %v, %v", roomId, err)
+				b.sendErrorMessage(u)
+				return
+			}
+			quorum = 0
+		}
+		b.setRevealPolicy(roomId, policy, quorum, u)
+	}
+}
+
+func (b *BotApp) setRevealPolicy(roomId string, policy model.RevealPolicy, quorum int, u *tgbot.Update) {
+	if err := b.roomService.SetRevealPolicyRoom(roomId, policy, quorum); err != nil {
+		lgr.Printf("[ERROR] unable to set reveal policy for room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowRoomSettings("", roomId, u)
+}
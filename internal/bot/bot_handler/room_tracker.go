@@ -0,0 +1,139 @@
+package bot_handler
+
+import (
+	"fmt"
+	"github.com/go-pkgz/lgr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"gotestbot/internal/webhook"
+	"gotestbot/sdk/tgbot"
+	"strings"
+	"time"
+)
+
+const (
+	JobTrackerWriteBack = "TRACKER_WRITE_BACK"
+
+	defaultGitHubPointsLabel = "points: "
+)
+
+// HandleSetTracker binds the room to a tracker: KIND, then URL and USER for Jira, TOKEN and FIELD.
+func (b *BotApp) HandleSetTracker(u *tgbot.Update) {
+
+	if u.HasAction(view.ActionSetTracker) {
+		roomId := u.GetButton().GetData("roomId")
+		if !b.authorize(u, service.PermManageRoom, roomId) {
+			return
+		}
+
+		kind := u.GetButton().GetData("kind")
+		switch kind {
+		case "":
+			_, _ = b.view.AddTrackerKind(roomId, u)
+		case "OFF":
+			if err := b.trackerService.DeleteTracker(roomId); err != nil {
+				lgr.Printf("[ERROR] unable to delete tracker of room: %v, %v", roomId, err)
+				b.sendErrorMessage(u)
+				return
+			}
+			u.FinishChain().FlushChatInfo()
+			_, _ = b.view.ShowRoomSettings("", roomId, u)
+		case string(model.TrackerJira):
+			u.StartChain(string(view.ActionSetTracker)).StartChainStep("URL").
+				AddChainData("roomId", roomId).AddChainData("kind", kind).FlushChatInfo()
+			_, _ = b.view.ErrorMessageText("Введите адрес Jira, например https://company.atlassian.net", u)
+		default:
+			u.StartChain(string(view.ActionSetTracker)).StartChainStep("TOKEN").
+				AddChainData("roomId", roomId).AddChainData("kind", kind).FlushChatInfo()
+			_, _ = b.view.ErrorMessageText("Введите personal access token GitHub с доступом к issues", u)
+		}
+		return
+	}
+
+	text := strings.TrimSpace(u.GetText())
+	switch u.GetChainStep() {
+	case "URL":
+		if !strings.HasPrefix(text, "https://") || webhook.ValidateUrl(text) != nil {
+			_, _ = b.view.ErrorMessageText("❗️ Адрес должен начинаться с https:// и быть доступен из интернета", u)
+			return
+		}
+		u.StartChainStep("USER").AddChainData("baseUrl", strings.TrimRight(text, "/")).FlushChatInfo()
+		_, _ = b.view.ErrorMessageText("Введите email пользователя Jira Cloud или '-', если используется персональный токен Jira Server", u)
+
+	case "USER":
+		if text == "-" {
+			text = ""
+		}
+		u.StartChainStep("TOKEN").AddChainData("userName", text).FlushChatInfo()
+		_, _ = b.view.ErrorMessageText("Введите API токен", u)
+
+	case "TOKEN":
+		if text == "" {
+			return
+		}
+		// the token should not stay in the chat history
+		_, _ = b.view.NewDeleteMessage(u.GetChatId(), u.GetMessageId())
+		u.StartChainStep("FIELD").AddChainData("token", text).FlushChatInfo()
+		if model.TrackerKind(u.GetChainData("kind")) == model.TrackerJira {
+			_, _ = b.view.ErrorMessageText("Введите id поля story points, например customfield_10016", u)
+		} else {
+			_, _ = b.view.ErrorMessageText(fmt.Sprintf("Введите префикс метки с оценкой или '-' для '%v'", defaultGitHubPointsLabel), u)
+		}
+
+	case "FIELD":
+		if text == "" {
+			return
+		}
+		kind := model.TrackerKind(u.GetChainData("kind"))
+		if kind == model.TrackerGitHub && text == "-" {
+			text = defaultGitHubPointsLabel
+		}
+		roomId := u.GetChainData("roomId")
+		roomUuid, _ := uuid.Parse(roomId)
+		err := b.trackerService.SaveTracker(model.TrackerConfig{
+			RoomId:      roomUuid,
+			Kind:        kind,
+			BaseUrl:     u.GetChainData("baseUrl"),
+			UserName:    u.GetChainData("userName"),
+			Token:       u.GetChainData("token"),
+			PointsField: text,
+			CreatedDate: time.Now(),
+		})
+		u.FinishChain().FlushChatInfo()
+		if errors.Is(err, service.ErrNoTrackerSecret) {
+			_, _ = b.view.ErrorMessageText("❗️ Трекеры недоступны: администратор бота не задал TRACKER_SECRET", u)
+			return
+		}
+		if errors.Is(err, webhook.ErrForbiddenUrl) {
+			_, _ = b.view.ErrorMessageText("❗️ Адрес трекера должен начинаться с https:// и быть доступен из интернета", u)
+			return
+		}
+		if err != nil {
+			lgr.Printf("[ERROR] unable to save tracker of room: %v, %v", roomId, err)
+			_, _ = b.view.ErrorMessageText("❗️ Не удалось сохранить настройки трекера", u)
+			return
+		}
+		_, _ = b.view.ShowRoomSettings("✅ Трекер подключен\n\n", roomId, u)
+	}
+}
+
+// scheduleWriteBack writes the final grade to the tracker in the background, so a slow tracker does not hold the chat.
+func (b *BotApp) scheduleWriteBack(taskId, roomId string) {
+	payload := model.JobPayload{"taskId": taskId, "roomId": roomId}
+	if err := b.scheduler.ScheduleWithAttempts(JobTrackerWriteBack, time.Now(), payload, 1); err != nil {
+		lgr.Printf("[ERROR] unable to schedule tracker write back, taskId: %v, %v", taskId, err)
+	}
+}
+
+// handleWriteBack runs once, a failure is reported to the owner who can fix the tracker settings and grade again.
+func (b *BotApp) handleWriteBack(payload model.JobPayload) error {
+	taskId, roomId := payload["taskId"], payload["roomId"]
+	if err := b.trackerService.WriteBackGrade(taskId); err != nil {
+		_, _ = b.view.ShowTrackerError(roomId, fmt.Sprintf("Не удалось записать оценку в трекер: %v", err))
+		return errors.Wrapf(err, "unable to write grade back to tracker, taskId: %v", taskId)
+	}
+	return nil
+}
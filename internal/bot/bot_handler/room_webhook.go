@@ -0,0 +1,59 @@
+package bot_handler
+
+import (
+	"fmt"
+	"github.com/go-pkgz/lgr"
+	"gotestbot/internal/bot/view"
+	"gotestbot/internal/service"
+	"gotestbot/sdk/tgbot"
+	"strings"
+)
+
+// HandleWebhooks lists, adds and deletes webhooks of the room. Adding waits for the url in the URL step.
+func (b *BotApp) HandleWebhooks(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	if !u.IsButton() {
+		roomId = u.GetChainData("roomId")
+	}
+	if !b.authorize(u, service.PermManageRoom, roomId) {
+		return
+	}
+
+	switch {
+	case u.HasAction(view.ActionShowWebhooks):
+		b.showWebhooks("", roomId, u)
+
+	case u.HasAction(view.ActionDeleteWebhook):
+		if err := b.webhookService.DeleteWebhook(roomId, u.GetButton().GetData("webhookId")); err != nil {
+			lgr.Printf("[ERROR] unable to delete webhook of room: %v, %v", roomId, err)
+			b.sendErrorMessage(u)
+			return
+		}
+		b.showWebhooks("", roomId, u)
+
+	case u.HasAction(view.ActionAddWebhook):
+		u.StartChain(string(view.ActionAddWebhook)).StartChainStep("URL").
+			AddChainData("roomId", roomId).FlushChatInfo()
+		_, _ = b.view.ErrorMessageText("Введите адрес, на который отправлять события, например https://example.com/hooks/planning", u)
+
+	case u.GetChainStep() == "URL":
+		hook, err := b.webhookService.AddWebhook(roomId, strings.TrimSpace(u.GetText()))
+		if err != nil {
+			lgr.Printf("[WARN] unable to add webhook to room: %v, %v", roomId, err)
+			_, _ = b.view.ErrorMessageText("❗️ Нужен публичный https адрес, попробуйте еще раз", u)
+			return
+		}
+		u.FinishChain().FlushChatInfo()
+		b.showWebhooks(fmt.Sprintf("✅ Вебхук добавлен, секрет для проверки подписи:\n`%s`\n\n", hook.Secret), roomId, u)
+	}
+}
+
+func (b *BotApp) showWebhooks(prefix, roomId string, u *tgbot.Update) {
+	hooks, err := b.webhookService.GetWebhooks(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get webhooks of room: %v, %v", roomId, err)
+		b.sendErrorMessage(u)
+		return
+	}
+	_, _ = b.view.ShowWebhooks(prefix, roomId, hooks, u)
+}
@@ -0,0 +1,66 @@
+package bot_handler
+
+import (
+	log "github.com/go-pkgz/lgr"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+	"strconv"
+	"time"
+)
+
+const (
+	JobTaskTimer = "TASK_TIMER"
+
+	timerTick = 15 * time.Second
+)
+
+func (b *BotApp) registerJobs() {
+	b.scheduler.Register(JobTaskTimer, b.handleTaskTimer)
+	b.scheduler.Register(JobBatchDeadline, b.handleBatchDeadline)
+	b.scheduler.Register(JobTrackerWriteBack, b.handleWriteBack)
+}
+
+// startTaskTimer schedules the first refresh of the remaining time on the vote message.
+func (b *BotApp) startTaskTimer(taskId, roomId string, messageId int) {
+	b.scheduleTaskTimer(model.JobPayload{
+		"taskId":    taskId,
+		"roomId":    roomId,
+		"messageId": strconv.Itoa(messageId),
+	})
+}
+
+func (b *BotApp) scheduleTaskTimer(payload model.JobPayload) {
+	if err := b.scheduler.Schedule(JobTaskTimer, time.Now().Add(timerTick), payload); err != nil {
+		log.Printf("[ERROR] unable to schedule task timer, taskId: %v, %v", payload["taskId"], err)
+	}
+}
+
+// handleTaskTimer refreshes the remaining time and reveals the task when it runs out.
+// The countdown stops once the task is finished or published again as another message.
+func (b *BotApp) handleTaskTimer(payload model.JobPayload) error {
+	taskId, roomId := payload["taskId"], payload["roomId"]
+	messageId, _ := strconv.Atoi(payload["messageId"])
+
+	task, err := b.taskService.GetTaskById(taskId)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get task for timer, taskId: %v", taskId)
+	}
+	if task.Finished || task.MessageId != messageId {
+		return nil
+	}
+	room, err := b.roomService.GetRoomById(roomId)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get room for timer, roomId: %v", roomId)
+	}
+	if !room.HasTimer() {
+		return nil
+	}
+
+	if time.Now().After(room.TaskDeadline(task)) {
+		b.revealTask(taskId, roomId, nil)
+		return nil
+	}
+	_, _ = b.view.ShowTaskView(0, taskId, roomId, nil)
+	b.scheduleTaskTimer(payload)
+	return nil
+}
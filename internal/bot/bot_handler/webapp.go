@@ -0,0 +1,57 @@
+package bot_handler
+
+import (
+	log "github.com/go-pkgz/lgr"
+	"gotestbot/internal/service"
+	"gotestbot/sdk/tgbot"
+)
+
+// HandleOpenBoard sends the button opening the Web App board of the room.
+func (b *BotApp) HandleOpenBoard(u *tgbot.Update) {
+	roomId := u.GetButton().GetData("roomId")
+	if !b.authorize(u, service.PermViewRoom, roomId) {
+		return
+	}
+	if _, err := b.view.ShowBoardButton(roomId, u); err != nil {
+		b.sendErrorMessage(u)
+	}
+}
+
+// AfterWebAppRate finishes a vote made in the Web App like a vote with a button: the task message is refreshed
+// and the task or the batch is revealed once enough votes are collected.
+func (b *BotApp) AfterWebAppRate(taskId, roomId string) {
+	b.checkVotes(taskId, roomId)
+}
+
+// checkVotes reveals the task or its batch when enough votes are collected, otherwise refreshes the task message.
+func (b *BotApp) checkVotes(taskId, roomId string) {
+	task, err := b.taskService.GetTaskById(taskId)
+	if err != nil {
+		log.Printf("[ERROR] unable to get task by taskId: %v, %v", taskId, err)
+		return
+	}
+
+	if task.BatchId.Valid {
+		batchId := task.BatchId.UUID.String()
+		finished, err := b.taskService.BatchFinished(batchId)
+		if err != nil {
+			log.Printf("[ERROR] %v", err)
+			return
+		}
+		if finished {
+			b.revealBatch(batchId, roomId)
+		}
+		return
+	}
+
+	finished, err := b.taskService.TaskFinished(taskId)
+	if err != nil {
+		log.Printf("[ERROR] unable to check if task is finished: %v, %v", taskId, err)
+		return
+	}
+	if finished {
+		b.revealTask(taskId, roomId, nil)
+	} else {
+		_, _ = b.view.ShowTaskView(0, taskId, roomId, nil)
+	}
+}
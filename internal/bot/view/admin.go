@@ -0,0 +1,187 @@
+package view
+
+import (
+	"fmt"
+	"github.com/go-pkgz/lgr"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+	"strconv"
+)
+
+// ShowRoomAdmin shows the owner's screen for renaming the room, rebinding its chat, appointing co-facilitators,
+// removing members and handing the room over.
+func (v *View) ShowRoomAdmin(prefix, roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	chat := "не привязан"
+	if room.ChatId != 0 {
+		chat = "привязан"
+	}
+
+	renameBtn := v.createButton(ActionRenameRoom, map[string]string{"roomId": roomId})
+	rebindBtn := v.createButton(ActionRebindChat, map[string]string{"roomId": roomId})
+	removeBtn := v.createButton(ActionRemoveMember, map[string]string{"roomId": roomId})
+	transferBtn := v.createButton(ActionTransferRoom, map[string]string{"roomId": roomId})
+	facilitatorsBtn := v.createButton(ActionSetFacilitator, map[string]string{"roomId": roomId})
+	backBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": roomId})
+
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text(prefix+fmt.Sprintf("🛠 Управление комнатой *%v*\n\n💬 Чат: %v", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name), chat)).
+		AddKeyboardRow().AddButton("✏️ Переименовать", renameBtn.Id).AddButton("🔗 Сменить чат", rebindBtn.Id).
+		AddKeyboardRow().AddButton("⭐️ Ведущие", facilitatorsBtn.Id).AddButton("👤 Удалить участника", removeBtn.Id).
+		AddKeyboardRow().AddButton("👑 Передать комнату", transferBtn.Id).
+		AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowAdminMembers lists the members except the owner, a pressed member button comes back with the action and userId.
+func (v *View) ShowAdminMembers(text string, action tgbot.Action, roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	members, err := v.roomProv.GetMembersByRoomId(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get members by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton())
+	count := 0
+	for _, member := range members {
+		if member.UserId == room.UserId {
+			continue
+		}
+		memberBtn := v.createButton(action, map[string]string{"roomId": roomId, "userId": strconv.FormatInt(member.UserId, 10)})
+		builder.AddKeyboardRow().AddButton(member.DisplayName, memberBtn.Id)
+		count++
+	}
+	if count == 0 {
+		text = "В комнате нет других участников"
+	}
+	backBtn := v.createButton(ActionShowRoomAdmin, map[string]string{"roomId": roomId})
+	builder.Text(text).AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ConfirmRoomAdmin asks to confirm an administrative change, the confirm button repeats the action with confirm=yes.
+func (v *View) ConfirmRoomAdmin(text string, action tgbot.Action, data map[string]string, u *tgbot.Update) (tgbotapi.Message, error) {
+	confirmData := map[string]string{"confirm": "yes"}
+	for key, value := range data {
+		confirmData[key] = value
+	}
+	confirmBtn := v.createButton(action, confirmData)
+	cancelBtn := v.createButton(ActionShowRoomAdmin, map[string]string{"roomId": data["roomId"]})
+
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text(text).
+		AddKeyboardRow().AddButton("✅ Подтвердить", confirmBtn.Id).AddButton("Отмена", cancelBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+func (v *View) AddRoomNewName(room model.Room, u *tgbot.Update) (tgbotapi.Message, error) {
+	cancelBtn := v.createButton(ActionShowRoomAdmin, map[string]string{"roomId": room.Id.String()})
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text(fmt.Sprintf("Введите новое название комнаты *%v*", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name))).
+		AddKeyboardRow().AddButton("Отмена", cancelBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// AddRoomNewChat asks to send the room to the new chat, the message posted there identifies the chat.
+func (v *View) AddRoomNewChat(room model.Room, u *tgbot.Update) (tgbotapi.Message, error) {
+	cancelBtn := v.createButton(ActionShowRoomAdmin, map[string]string{"roomId": room.Id.String()})
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text("Добавьте бота в новый чат, затем нажмите *Выбрать чат* и отправьте комнату в него").
+		AddKeyboardRow().AddButtonSwitch("📢 Выбрать чат", room.Name).
+		AddKeyboardRow().AddButton("Отмена", cancelBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowRoomNotice tells the room chat about a change made by the owner.
+func (v *View) ShowRoomNotice(chatId int64, text string) (tgbotapi.Message, error) {
+	if chatId == 0 {
+		return tgbotapi.Message{}, nil
+	}
+	builder := new(tgbot.MessageBuilder).
+		NewMessage(chatId).
+		Text(text)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowFacilitators lists the members except the owner, a pressed member is promoted to a co-facilitator or demoted back to a voter.
+func (v *View) ShowFacilitators(roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	members, err := v.roomProv.GetMembersByRoomId(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get members by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+
+	text := "⭐️ Ведущие комнаты\n\nВедущие публикуют задачи, раскрывают оценки и выставляют итоговую оценку. " +
+		"Нажмите на участника, чтобы назначить его ведущим или снять с него эту роль"
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton())
+	count := 0
+	for _, member := range members {
+		if member.UserId == room.UserId {
+			continue
+		}
+		title := member.DisplayName
+		if member.Role == model.RoleFacilitator {
+			title = "⭐️ " + title
+		}
+		memberBtn := v.createButton(ActionSetFacilitator, map[string]string{"roomId": roomId, "userId": strconv.FormatInt(member.UserId, 10)})
+		builder.AddKeyboardRow().AddButton(title, memberBtn.Id)
+		count++
+	}
+	if count == 0 {
+		text = "В комнате нет других участников"
+	}
+	backBtn := v.createButton(ActionShowRoomAdmin, map[string]string{"roomId": roomId})
+	builder.Text(text).AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowChatLost tells the owner that the bot was removed from the chat of the room and offers to bind another chat.
+func (v *View) ShowChatLost(room model.Room, chatTitle string) (tgbotapi.Message, error) {
+	rebindBtn := v.createButton(ActionRebindChat, map[string]string{"roomId": room.Id.String()})
+	roomBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": room.Id.String()})
+
+	text := fmt.Sprintf("⚠️ Бота удалили из чата *%v*, комната *%v* больше не привязана к чату. "+
+		"Задачи не будут публиковаться, пока вы не привяжете комнату к чату",
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, chatTitle), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name))
+	builder := new(tgbot.MessageBuilder).
+		NewMessage(room.UserId).
+		Text(text).
+		AddKeyboardRow().AddButton("🔗 Привязать чат", rebindBtn.Id).
+		AddKeyboardRow().AddButton("Открыть комнату", roomBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
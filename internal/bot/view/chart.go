@@ -0,0 +1,116 @@
+package view
+
+import (
+	"bytes"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"gotestbot/internal/service/model"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+)
+
+const (
+	chartWidth   = 640
+	chartHeight  = 360
+	chartPadding = 40
+)
+
+var (
+	chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	chartAxis       = color.RGBA{R: 0x90, G: 0x90, B: 0x90, A: 0xff}
+	chartBar        = color.RGBA{R: 0x4a, G: 0x90, B: 0xe2, A: 0xff}
+	chartGradeBar   = color.RGBA{R: 0x3c, G: 0xb3, B: 0x71, A: 0xff}
+	chartAbstention = color.RGBA{R: 0xc8, G: 0xc8, B: 0xc8, A: 0xff}
+	chartText       = color.RGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xff}
+)
+
+// chartLabels replaces abstention symbols, the built-in font only has ASCII glyphs.
+var chartLabels = map[model.RateKind]string{
+	model.RateKindCoffee:   "coffee",
+	model.RateKindUnknown:  "?",
+	model.RateKindInfinity: "inf",
+}
+
+type chartColumn struct {
+	label string
+	count int
+	color color.Color
+}
+
+// renderChart draws a bar per card of the scale plus used abstentions, the bar of the final grade is highlighted.
+func renderChart(scale model.Scale, rates []model.Rate, grade int32) ([]byte, error) {
+	counts := map[int32]int{}
+	abstentions := map[model.RateKind]int{}
+	for _, rate := range rates {
+		if rate.IsEstimate() {
+			counts[rate.Sum]++
+		} else {
+			abstentions[rate.Kind]++
+		}
+	}
+
+	var columns []chartColumn
+	for _, card := range scale.Cards() {
+		c := chartColumn{label: card.Label, count: counts[card.Points], color: chartBar}
+		if card.Points == grade {
+			c.color = chartGradeBar
+		}
+		columns = append(columns, c)
+	}
+	for _, card := range model.Abstentions {
+		if abstentions[card.Kind] > 0 {
+			columns = append(columns, chartColumn{label: chartLabels[card.Kind], count: abstentions[card.Kind], color: chartAbstention})
+		}
+	}
+
+	maxCount := 1
+	for _, c := range columns {
+		if c.count > maxCount {
+			maxCount = c.count
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: chartBackground}, image.Point{}, draw.Src)
+
+	baseline := chartHeight - chartPadding
+	plotHeight := baseline - chartPadding - 20
+	draw.Draw(img, image.Rect(chartPadding/2, baseline, chartWidth-chartPadding/2, baseline+1),
+		&image.Uniform{C: chartAxis}, image.Point{}, draw.Src)
+
+	slot := (chartWidth - chartPadding) / len(columns)
+	barWidth := slot * 2 / 3
+	for i, c := range columns {
+		left := chartPadding/2 + i*slot + (slot-barWidth)/2
+		height := plotHeight * c.count / maxCount
+		draw.Draw(img, image.Rect(left, baseline-height, left+barWidth, baseline),
+			&image.Uniform{C: c.color}, image.Point{}, draw.Src)
+
+		center := left + barWidth/2
+		drawCentered(img, c.label, center, baseline+18)
+		if c.count > 0 {
+			drawCentered(img, strconv.Itoa(c.count), center, baseline-height-6)
+		}
+		if c.color == chartGradeBar {
+			drawCentered(img, "grade", center, baseline-height-22)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawCentered(img draw.Image, text string, x, y int) {
+	face := basicfont.Face7x13
+	d := &font.Drawer{Dst: img, Src: &image.Uniform{C: chartText}, Face: face}
+	width := d.MeasureString(text).Round()
+	d.Dot = fixed.P(x-width/2, y)
+	d.DrawString(text)
+}
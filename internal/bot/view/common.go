@@ -6,7 +6,9 @@ import (
 	"github.com/go-pkgz/lgr"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/google/uuid"
+	"gotestbot/internal/service/model"
 	"gotestbot/sdk/tgbot"
+	"strings"
 )
 
 func (v *View) createButton(action tgbot.Action, data map[string]string) *tgbot.Button {
@@ -41,3 +43,40 @@ func logIfError(send tgbotapi.Message, err error) (tgbotapi.Message, error) {
 func userLink(user *tgbot.User) string {
 	return fmt.Sprintf("[%s](tg://user?id=%d)", user.DisplayName, user.UserId)
 }
+
+func memberLink(member model.Member) string {
+	return fmt.Sprintf("[%s](tg://user?id=%d)", member.DisplayName, member.UserId)
+}
+
+func membersText(members []model.Member) string {
+	var voters, observers string
+	for _, member := range members {
+		switch member.Role {
+		case model.RoleObserver:
+			observers += "- " + memberLink(member) + "\n"
+		case model.RoleFacilitator:
+			voters += "- " + memberLink(member) + " ⭐️\n"
+		default:
+			voters += "- " + memberLink(member) + "\n"
+		}
+	}
+
+	text := "Участники:\n" + voters
+	if observers != "" {
+		text += "\nНаблюдатели:\n" + observers
+	}
+	return text
+}
+
+func observersText(members []model.Member) string {
+	var names []string
+	for _, member := range members {
+		if member.IsObserver() {
+			names = append(names, memberLink(member))
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "\n👀 Наблюдают: " + strings.Join(names, ", ") + "\n"
+}
@@ -14,11 +14,40 @@ const (
 	ActionShowRooms         = tgbot.Action("SHOW_ROOMS")
 	ActionShowRoom          = tgbot.Action("SHOW_ROOM")
 	ActionJoinRoom          = tgbot.Action("JOIN_ROOM")
+	ActionObserveRoom       = tgbot.Action("OBSERVE_ROOM")
 	ActionBotAdded          = tgbot.Action("JOIN_ROOM")
 	ActionSetGroupOfRoom    = tgbot.Action("SET_GROUP_OF_ROOM")
 	ActionFinishRoom        = tgbot.Action("FINISH_ROOM")
 	ActionRoomSettingTimes  = tgbot.Action("SETTINGS_ROOM_TIMER")
+	ActionRoomSettingScale  = tgbot.Action("SETTINGS_ROOM_SCALE")
+	ActionShowRoomSettings  = tgbot.Action("SHOW_ROOM_SETTINGS")
+	ActionShowRoomAdmin     = tgbot.Action("SHOW_ROOM_ADMIN")
+	ActionRenameRoom        = tgbot.Action("RENAME_ROOM")
+	ActionRebindChat        = tgbot.Action("REBIND_CHAT")
+	ActionRemoveMember      = tgbot.Action("REMOVE_MEMBER")
+	ActionTransferRoom      = tgbot.Action("TRANSFER_ROOM")
+	ActionSetFacilitator    = tgbot.Action("SET_FACILITATOR")
+	ActionSetRoomTimer      = tgbot.Action("SET_ROOM_TIMER")
+	ActionSetRevealPolicy   = tgbot.Action("SET_REVEAL_POLICY")
+	ActionSetAnonymous      = tgbot.Action("SET_ANONYMOUS")
+	ActionSetRoomMode       = tgbot.Action("SET_ROOM_MODE")
+	ActionSetAutoJoin       = tgbot.Action("SET_AUTO_JOIN")
+	ActionSetTracker        = tgbot.Action("SET_TRACKER")
+	ActionShowWebhooks      = tgbot.Action("SHOW_WEBHOOKS")
+	ActionAddWebhook        = tgbot.Action("ADD_WEBHOOK")
+	ActionDeleteWebhook     = tgbot.Action("DELETE_WEBHOOK")
+	ActionPublishBatch      = tgbot.Action("PUBLISH_BATCH")
+	ActionOpenBoard         = tgbot.Action("OPEN_BOARD")
+	ActionExportRoom        = tgbot.Action("EXPORT_ROOM")
+	ActionCloneRoom         = tgbot.Action("CLONE_ROOM")
+	ActionSaveTemplate      = tgbot.Action("SAVE_TEMPLATE")
+	ActionShowTemplates     = tgbot.Action("SHOW_TEMPLATES")
+	ActionUseTemplate       = tgbot.Action("USE_TEMPLATE")
+	ActionDeleteTemplate    = tgbot.Action("DELETE_TEMPLATE")
 	ActionCreateTask        = tgbot.Action("ADD_TASK")
+	ActionBulkCreateTasks   = tgbot.Action("BULK_ADD_TASKS")
+	ActionBulkSaveTasks     = tgbot.Action("BULK_SAVE_TASKS")
+	ActionImportTasks       = tgbot.Action("IMPORT_TASKS")
 	ActionShowTasks         = tgbot.Action("SHOW_TASKS")
 	ActionShowTask          = tgbot.Action("SHOW_TASK")
 	ActionNextTask          = tgbot.Action("NEXT_TASK")
@@ -27,6 +56,7 @@ const (
 	ActionSaveTaskAndCancel = tgbot.Action("SAVE_TASK_AND_CANCEL")
 	ActionFinishTask        = tgbot.Action("FINISH_TASK")
 	ActionAddRate           = tgbot.Action("TASK_RATE")
+	ActionAddBallotRate     = tgbot.Action("BALLOT_RATE")
 	ActionRevoteTaskRate    = tgbot.Action("REVOTE_TASK_RATE")
 	ActionFinishTaskRate    = tgbot.Action("FINISH_TASK_RATE")
 )
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"github.com/go-pkgz/lgr"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gotestbot/internal/service/model"
 	"gotestbot/sdk/tgbot"
+	"strconv"
+	"strings"
 )
 
 func (v *View) AddRoomName(u *tgbot.Update) (tgbotapi.Message, error) {
@@ -15,14 +18,286 @@ func (v *View) AddRoomName(u *tgbot.Update) (tgbotapi.Message, error) {
 	return logIfError(v.tg.Send(builder.Build()))
 }
 
+func (v *View) AddScaleRoom(prefix string, u *tgbot.Update) (tgbotapi.Message, error) {
+	builder := new(tgbot.MessageBuilder).
+		NewMessage(u.GetUserId()).
+		Text(prefix + "Выберите шкалу оценки")
+
+	for _, scale := range model.Scales {
+		var labels []string
+		for _, card := range scale.Cards() {
+			labels = append(labels, card.Label)
+		}
+		scaleBtn := v.createButton(ActionRoomSettingScale, map[string]string{"scale": string(scale)})
+		builder.AddKeyboardRow().AddButton(fmt.Sprintf("%v: %v", scale.Title(), strings.Join(labels, ", ")), scaleBtn.Id)
+	}
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// TimerOptions are the countdowns in seconds a room can use, zero turns the timer off.
+var TimerOptions = []int{0, 30, 60, 120, 300}
+
+func timerTitle(seconds int) string {
+	switch {
+	case seconds <= 0:
+		return "выключен"
+	case seconds < 60:
+		return fmt.Sprintf("%d сек", seconds)
+	default:
+		return fmt.Sprintf("%d мин", seconds/60)
+	}
+}
+
 func (v *View) AddSettingRoom(prefix string, u *tgbot.Update) (tgbotapi.Message, error) {
-	timerBtn := v.createButton(ActionRoomSettingTimes, nil)
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text(prefix + "Выберите настройка для комнаты").
+		AddKeyboardRow()
+
+	for _, seconds := range TimerOptions[1:] {
+		timerBtn := v.createButton(ActionRoomSettingTimes, map[string]string{"timer": strconv.Itoa(seconds)})
+		builder.AddButton("⏳ "+timerTitle(seconds), timerBtn.Id)
+	}
+	noTimerBtn := v.createButton(ActionRoomSettingTimes, map[string]string{"timer": "0"})
+	builder.AddKeyboardRow().AddButton("❌ Не использовать таймер", noTimerBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+func (v *View) ShowRoomSettings(prefix, roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+
+	anonymous := "выключено"
+	if room.Anonymous {
+		anonymous = "включено"
+	}
+	policyQuorum := room.RevealQuorum
+	if room.RevealPolicy == model.RevealPresent {
+		policyQuorum = room.PresentHours
+	}
+	autoJoin := "выключено"
+	if room.AutoJoin {
+		autoJoin = "включено"
+	}
+	tracker, err := v.roomProv.GetTracker(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get tracker by roomId: %v, %v", roomId, err)
+	}
+	text := fmt.Sprintf("Настройки комнаты - *%v*\n\n📌 Режим: *%v*\n⏳ Таймер: *%v*\n🔓 Раскрытие: *%v*\n🕶 Анонимное раскрытие: *%v*\n🙋 Автовступление: *%v*\n🔗 Трекер: *%v*",
+		room.Name, roomModeTitle(room.Mode), timerTitle(room.Timer), revealPolicyTitle(room.RevealPolicy, policyQuorum), anonymous,
+		autoJoin, trackerTitle(tracker))
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text(prefix + text).
+		AddKeyboardRow()
+
+	for _, seconds := range TimerOptions {
+		title := "⏳ " + timerTitle(seconds)
+		if seconds == room.Timer {
+			title = "✅ " + timerTitle(seconds)
+		}
+		timerBtn := v.createButton(ActionSetRoomTimer, map[string]string{"roomId": roomId, "timer": strconv.Itoa(seconds)})
+		builder.AddButton(title, timerBtn.Id)
+	}
+
+	for i, policy := range []model.RevealPolicy{model.RevealAll, model.RevealPresent, model.RevealPercent, model.RevealCount} {
+		if i%2 == 0 {
+			builder.AddKeyboardRow()
+		}
+		title := "🔓 " + revealPolicyTitle(policy, 0)
+		if policy == room.RevealPolicy {
+			title = "✅ " + revealPolicyTitle(policy, 0)
+		}
+		policyBtn := v.createButton(ActionSetRevealPolicy, map[string]string{"roomId": roomId, "policy": string(policy)})
+		builder.AddButton(title, policyBtn.Id)
+	}
+
+	anonymousTitle := "🕶 Включить анонимное раскрытие"
+	if room.Anonymous {
+		anonymousTitle = "👁 Выключить анонимное раскрытие"
+	}
+	anonymousBtn := v.createButton(ActionSetAnonymous, map[string]string{"roomId": roomId, "anonymous": strconv.FormatBool(!room.Anonymous)})
+	builder.AddKeyboardRow().AddButton(anonymousTitle, anonymousBtn.Id)
+
+	// with auto-join people joining the chat or voting on a published task become voters without pressing "Присоединиться"
+	autoJoinTitle := "🙋 Включить автовступление"
+	if room.AutoJoin {
+		autoJoinTitle = "🚪 Выключить автовступление"
+	}
+	autoJoinBtn := v.createButton(ActionSetAutoJoin, map[string]string{"roomId": roomId, "autoJoin": strconv.FormatBool(!room.AutoJoin)})
+	builder.AddKeyboardRow().AddButton(autoJoinTitle, autoJoinBtn.Id)
+
+	mode, modeTitle := model.ModeAsync, "📬 Перейти в асинхронный режим"
+	if room.IsAsync() {
+		mode, modeTitle = model.ModeLive, "🗣 Перейти в режим живой оценки"
+	}
+	modeBtn := v.createButton(ActionSetRoomMode, map[string]string{"roomId": roomId, "mode": string(mode)})
+	builder.AddKeyboardRow().AddButton(modeTitle, modeBtn.Id)
+
+	trackerBtn := v.createButton(ActionSetTracker, map[string]string{"roomId": roomId})
+	webhooksBtn := v.createButton(ActionShowWebhooks, map[string]string{"roomId": roomId})
+	builder.AddKeyboardRow().AddButton("🔗 Настроить трекер", trackerBtn.Id).AddButton("🪝 Вебхуки", webhooksBtn.Id)
+
+	backBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": roomId})
+	builder.AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+func (v *View) ShowWebhooks(prefix, roomId string, hooks []model.Webhook, u *tgbot.Update) (tgbotapi.Message, error) {
+	text := "🪝 Вебхуки комнаты\n\nСобытия task.published, task.revealed, task.graded, room.finished и member.joined " +
+		"отправляются POST запросом в JSON, подпись HMAC-SHA256 тела - в заголовке X-Planning-Signature.\n"
+	if len(hooks) == 0 {
+		text += "\nВебхуков пока нет"
+	}
 
 	builder := new(tgbot.MessageBuilder).
-		NewMessage(u.GetUserId()).
-		Text(prefix+"Выберите настройка для комнаты").
-		AddKeyboardRow().AddButton("⏳ Использовать таймер", timerBtn.Id).
-		AddKeyboardRow().AddButton("❌ Не использовать таймер", timerBtn.Id)
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton())
+	for _, hook := range hooks {
+		deleteBtn := v.createButton(ActionDeleteWebhook, map[string]string{"roomId": roomId, "webhookId": hook.Id.String()})
+		builder.AddKeyboardRow().AddButton("❌ "+hook.Url, deleteBtn.Id)
+	}
+	addBtn := v.createButton(ActionAddWebhook, map[string]string{"roomId": roomId})
+	backBtn := v.createButton(ActionShowRoomSettings, map[string]string{"roomId": roomId})
+	builder.Text(prefix+text).
+		AddKeyboardRow().AddButton("➕ Добавить вебхук", addBtn.Id).
+		AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+func trackerTitle(tracker *model.TrackerConfig) string {
+	switch {
+	case tracker == nil:
+		return "не подключен"
+	case tracker.Token == "":
+		return "нужен токен, подключите трекер заново"
+	case tracker.Kind == model.TrackerJira:
+		return "Jira " + tracker.BaseUrl
+	default:
+		return "GitHub"
+	}
+}
+
+func (v *View) AddTrackerKind(roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	jiraBtn := v.createButton(ActionSetTracker, map[string]string{"roomId": roomId, "kind": string(model.TrackerJira)})
+	githubBtn := v.createButton(ActionSetTracker, map[string]string{"roomId": roomId, "kind": string(model.TrackerGitHub)})
+	offBtn := v.createButton(ActionSetTracker, map[string]string{"roomId": roomId, "kind": "OFF"})
+	backBtn := v.createButton(ActionShowRoomSettings, map[string]string{"roomId": roomId})
+
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text("По ссылке на задачу бот подтянет ее название из трекера, а итоговую оценку запишет обратно.\n\nВыберите трекер").
+		AddKeyboardRow().AddButton("Jira", jiraBtn.Id).AddButton("GitHub", githubBtn.Id).
+		AddKeyboardRow().AddButton("Отключить трекер", offBtn.Id).
+		AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowRoomFinished tells the room owner that the planning was finished.
+func (v *View) ShowRoomFinished(roomId string) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	roomBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": roomId})
+	builder := new(tgbot.MessageBuilder).
+		NewMessage(room.UserId).
+		Text(fmt.Sprintf("✅ Планирование в комнате *%s* завершено", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name))).
+		AddKeyboardRow().AddButton("Открыть комнату", roomBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowTrackerError tells the room owner that the tracker could not be reached.
+func (v *View) ShowTrackerError(roomId string, text string) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	builder := new(tgbot.MessageBuilder).
+		NewMessage(room.UserId).
+		Text(fmt.Sprintf("🔗 Комната: *%s*\n\n❗️ %s", room.Name, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text)))
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+func roomModeTitle(mode model.RoomMode) string {
+	if mode == model.ModeAsync {
+		return "асинхронный, личные бюллетени до дедлайна"
+	}
+	return "живая оценка в чате"
+}
+
+// DeadlineOptions are the offered batch deadlines, in hours from publishing.
+var DeadlineOptions = []int{4, 12, 24, 48, 72}
+
+func (v *View) AddBatchDeadline(roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text("Все неоцененные задачи комнаты будут разосланы участникам в личные сообщения.\n\nВыберите, сколько времени дать на голосование").
+		AddKeyboardRow()
+
+	for _, hours := range DeadlineOptions {
+		deadlineBtn := v.createButton(ActionPublishBatch, map[string]string{"roomId": roomId, "hours": strconv.Itoa(hours)})
+		builder.AddButton(fmt.Sprintf("%d ч", hours), deadlineBtn.Id)
+	}
+	backBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": roomId})
+	builder.AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// MaxPresentHours limits the presence window of the PRESENT reveal policy.
+const MaxPresentHours = 72
+
+func revealPolicyTitle(policy model.RevealPolicy, quorum int) string {
+	switch policy {
+	case model.RevealPercent:
+		if quorum > 0 {
+			return fmt.Sprintf("кворум %d%%", quorum)
+		}
+		return "кворум в %"
+	case model.RevealCount:
+		if quorum > 0 {
+			return fmt.Sprintf("%d голосов", quorum)
+		}
+		return "число голосов"
+	case model.RevealPresent:
+		if quorum > 0 {
+			return fmt.Sprintf("присутствующие за %d ч", quorum)
+		}
+		return "все присутствующие"
+	default:
+		return "все участники"
+	}
+}
+
+func (v *View) AddRevealQuorum(policy model.RevealPolicy, u *tgbot.Update) (tgbotapi.Message, error) {
+	text := "Введите число голосов, после которого задача раскрывается"
+	switch policy {
+	case model.RevealPercent:
+		text = "Введите процент участников (от 1 до 100), после голосов которых задача раскрывается"
+	case model.RevealPresent:
+		text = fmt.Sprintf("Введите, за сколько часов (от 1 до %d) голос по другой задаче комнаты делает участника присутствующим", MaxPresentHours)
+	}
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text(text)
 
 	return logIfError(v.tg.Send(builder.Build()))
 }
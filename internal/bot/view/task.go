@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"github.com/go-pkgz/lgr"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pkg/errors"
 	"gotestbot/internal/service/model"
 	tgbot2 "gotestbot/sdk/tgbot"
-	"math"
-	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// ErrNoRounds is returned by the result views of a task that has no voting rounds, so there is nothing to show.
+var ErrNoRounds = errors.New("task has no rounds")
+
 func (v *View) AddTaskName(u *tgbot2.Update) (tgbotapi.Message, error) {
 	builder := new(tgbot2.MessageBuilder).
 		Message(u.GetUserId(), u.GetMessageId()).
@@ -28,6 +31,87 @@ func (v *View) AddTaskUrl(u *tgbot2.Update) (tgbotapi.Message, error) {
 	return logIfError(v.tg.Send(builder.Build()))
 }
 
+func (v *View) AddBulkTasks(roomId string, u *tgbot2.Update) (tgbotapi.Message, error) {
+	cancelBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": roomId})
+	builder := new(tgbot2.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text("Отправьте список задач, по одной на строку:\n\n"+
+			"`Название задачи`\n`Название задачи | https://ссылка`\n`https://ссылка Название задачи`").
+		AddKeyboardRow().AddButton("Отмена", cancelBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// bulkPreviewLimit keeps the preview within the message size limit of Telegram.
+const bulkPreviewLimit = 50
+
+func (v *View) ShowBulkTasksPreview(roomId string, drafts []model.TaskDraft, invalid []model.InvalidLine, u *tgbot2.Update) (tgbotapi.Message, error) {
+	text := fmt.Sprintf("Будет создано задач: *%d*\n", len(drafts))
+	for i, draft := range drafts {
+		if i == bulkPreviewLimit {
+			text += fmt.Sprintf("... и еще %d\n", len(drafts)-bulkPreviewLimit)
+			break
+		}
+		link := ""
+		if draft.Url != "" {
+			link = " 🔗"
+		}
+		text += fmt.Sprintf("%d. %s%s\n", i+1, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, draft.Name), link)
+	}
+	if len(invalid) > 0 {
+		text += fmt.Sprintf("\n❗️ Пропущено строк: *%d*\n", len(invalid))
+		for i, line := range invalid {
+			if i == bulkPreviewLimit {
+				text += fmt.Sprintf("... и еще %d\n", len(invalid)-bulkPreviewLimit)
+				break
+			}
+			text += fmt.Sprintf("Строка %d: %s - %s\n", line.Number, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, line.Text), line.Reason)
+		}
+	}
+
+	builder := new(tgbot2.MessageBuilder).
+		NewMessage(u.GetUserId()).
+		Text(text)
+	if len(drafts) > 0 {
+		saveBtn := v.createButton(ActionBulkSaveTasks, map[string]string{"roomId": roomId})
+		builder.AddKeyboardRow().AddButton(fmt.Sprintf("💾 Сохранить %d", len(drafts)), saveBtn.Id)
+	}
+	retryBtn := v.createButton(ActionBulkCreateTasks, map[string]string{"roomId": roomId})
+	cancelBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": roomId})
+	builder.AddKeyboardRow().AddButton("✏️ Ввести заново", retryBtn.Id).AddButton("Отмена", cancelBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowImportPreview lists tasks found in the uploaded file and offers the rooms they can be imported to.
+func (v *View) ShowImportPreview(format string, drafts []model.TaskDraft, rooms []model.Room, u *tgbot2.Update) (tgbotapi.Message, error) {
+	text := fmt.Sprintf("📥 %s, найдено задач: *%d*\n", format, len(drafts))
+	for i, draft := range drafts {
+		if i == bulkPreviewLimit {
+			text += fmt.Sprintf("... и еще %d\n", len(drafts)-bulkPreviewLimit)
+			break
+		}
+		text += fmt.Sprintf("%d. %s\n", i+1, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, draft.Name))
+	}
+
+	builder := new(tgbot2.MessageBuilder).
+		NewMessage(u.GetUserId())
+	if len(rooms) == 0 {
+		text += "\n❗️ У вас нет комнат, в которые можно добавить задачи"
+	} else {
+		text += "\nВыберите комнату для импорта"
+	}
+	for _, room := range rooms {
+		roomBtn := v.createButton(ActionImportTasks, map[string]string{"roomId": room.Id.String()})
+		builder.AddKeyboardRow().AddButton(room.Name, roomBtn.Id)
+	}
+	cancelBtn := v.createButton(ActionStart, nil)
+	builder.Text(text).AddKeyboardRow().AddButton("Отмена", cancelBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
 func (v *View) AddSettingTask(prefix string, u *tgbot2.Update) (tgbotapi.Message, error) {
 	saveAndSendBtn := v.createButton(ActionSaveAndSendTask, nil)
 	saveAndNewBtn := v.createButton(ActionSaveAndSaveTask, nil)
@@ -43,15 +127,9 @@ func (v *View) AddSettingTask(prefix string, u *tgbot2.Update) (tgbotapi.Message
 	return logIfError(v.tg.Send(builder.Build()))
 }
 
-func (v *View) ShowTaskView(chatId int64, taskId string, roomId string, u *tgbot2.Update) (tgbotapi.Message, error) {
-	messageBuilder := new(tgbot2.MessageBuilder)
-	if chatId != 0 {
-		messageBuilder.NewMessage(chatId)
-	} else {
-		messageBuilder.Message(u.GetChatId(), u.GetMessageId()).
-			Edit(u.IsButton())
-	}
+const rateButtonsInRow = 6
 
+func (v *View) ShowTaskView(chatId int64, taskId string, roomId string, u *tgbot2.Update) (tgbotapi.Message, error) {
 	room, err := v.roomProv.GetRoomById(roomId)
 	if err != nil {
 		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %d", roomId)
@@ -66,9 +144,20 @@ func (v *View) ShowTaskView(chatId int64, taskId string, roomId string, u *tgbot
 	}
 	text += fmt.Sprintf("Задача: *%s*\n\n", task.Name)
 
-	users, err := v.roomProv.GetUsersByRoomId(roomId)
+	messageBuilder := new(tgbot2.MessageBuilder)
+	switch {
+	case chatId != 0:
+		messageBuilder.NewMessage(chatId)
+	case u != nil:
+		messageBuilder.Message(u.GetChatId(), u.GetMessageId()).
+			Edit(u.IsButton())
+	default:
+		messageBuilder.EditMessageTextAndMarkup(task.ChatId, task.MessageId)
+	}
+
+	members, err := v.roomProv.GetMembersByRoomId(roomId)
 	if err != nil {
-		lgr.Printf("[ERROR] unable to GetUsersByRoomId for roomId: %d, %v", roomId, err)
+		lgr.Printf("[ERROR] unable to GetMembersByRoomId for roomId: %v, %v", roomId, err)
 		return tgbotapi.Message{}, err
 	}
 
@@ -79,46 +168,138 @@ func (v *View) ShowTaskView(chatId int64, taskId string, roomId string, u *tgbot
 	}
 
 	userIdToRate := map[int64]*model.Rate{}
-	for _, rate := range rates {
-		userIdToRate[rate.UserId] = &rate
+	for i := range rates {
+		userIdToRate[rates[i].UserId] = &rates[i]
 	}
 
-	for _, user := range users {
-		rate := userIdToRate[user.UserId]
+	for _, member := range members {
+		if member.IsObserver() {
+			continue
+		}
+		rate := userIdToRate[member.UserId]
 		rateEmoji := "🐐"
 		if rate != nil && !task.Finished {
 			rateEmoji = "✅"
 		} else if rate != nil && task.Finished {
-			rateEmoji = string(rate.Sum)
+			rateEmoji = "✅"
+			if !room.Anonymous {
+				rateEmoji = room.Scale.RateLabel(*rate)
+			}
 		}
-		text += fmt.Sprintf("%s - %s\n", rateEmoji, userLink(&user))
+		text += fmt.Sprintf("%s - %s\n", rateEmoji, memberLink(member))
+	}
+	text += observersText(members)
+	if room.HasTimer() && !task.Finished {
+		text += fmt.Sprintf("\n⏳ Осталось %v", timeLeft(time.Until(room.TaskDeadline(task))))
 	}
 
 	messageBuilder.Text(text)
-	callbackQuery := u.Update.CallbackQuery
-	if callbackQuery != nil && callbackQuery.Message != nil && callbackQuery.Message.ReplyMarkup != nil &&
-		callbackQuery.Message.ReplyMarkup.InlineKeyboard != nil &&
-		callbackQuery.Message.ReplyMarkup.InlineKeyboard[0][0].Text == "0" {
-
-		messageBuilder.AddKeyboard(callbackQuery.Message.ReplyMarkup.InlineKeyboard)
+	if u != nil && u.HasAction(ActionAddRate) &&
+		u.CallbackQuery.Message != nil && u.CallbackQuery.Message.ReplyMarkup != nil {
+		messageBuilder.AddKeyboard(u.CallbackQuery.Message.ReplyMarkup.InlineKeyboard)
 
 	} else {
+		v.addRateKeyboard(messageBuilder, ActionAddRate, room.Scale.Cards(), model.Abstentions, taskId, roomId)
 		finishBtn := v.createButton(ActionFinishTask, map[string]string{"taskId": taskId, "roomId": roomId})
-		messageBuilder.
-			AddKeyboardRow().
-			AddButton("☕️", v.createButton(ActionAddRate, map[string]string{"sum": "0", "taskId": taskId, "roomId": roomId}).Id).
-			AddButton("0", v.createButton(ActionAddRate, map[string]string{"sum": "0", "taskId": taskId, "roomId": roomId}).Id).
-			AddButton("1", v.createButton(ActionAddRate, map[string]string{"sum": "1", "taskId": taskId, "roomId": roomId}).Id).
-			AddButton("2", v.createButton(ActionAddRate, map[string]string{"sum": "2", "taskId": taskId, "roomId": roomId}).Id).
-			AddButton("3", v.createButton(ActionAddRate, map[string]string{"sum": "3", "taskId": taskId, "roomId": roomId}).Id).
-			AddButton("5", v.createButton(ActionAddRate, map[string]string{"sum": "5", "taskId": taskId, "roomId": roomId}).Id).
-			AddButton("8", v.createButton(ActionAddRate, map[string]string{"sum": "8", "taskId": taskId, "roomId": roomId}).Id).
-			AddKeyboardRow().AddButton("Раскрыться", finishBtn.Id)
+		messageBuilder.AddKeyboardRow().AddButton("Раскрыться", finishBtn.Id)
 	}
 
 	return logIfError(v.tg.Send(messageBuilder.Build()))
 }
 
+// addRateKeyboard adds rows of scale cards and a row of abstentions, each button votes with the given action.
+func (v *View) addRateKeyboard(builder *tgbot2.MessageBuilder, action tgbot2.Action, cards, abstentions []model.Card, taskId, roomId string) {
+	for i, card := range cards {
+		if i%rateButtonsInRow == 0 {
+			builder.AddKeyboardRow()
+		}
+		rateBtn := v.createButton(action, map[string]string{"card": card.Label, "taskId": taskId, "roomId": roomId})
+		builder.AddButton(card.Label, rateBtn.Id)
+	}
+	builder.AddKeyboardRow()
+	for _, card := range abstentions {
+		rateBtn := v.createButton(action, map[string]string{"card": card.Label, "taskId": taskId, "roomId": roomId})
+		builder.AddButton(card.Label, rateBtn.Id)
+	}
+}
+
+// ShowBallotView sends the private ballot of a batch task to the user, or updates it after a vote when u is set.
+// Coffee breaks make no sense without a meeting, so the ballot offers the other abstentions only.
+func (v *View) ShowBallotView(userId int64, taskId, roomId string, u *tgbot2.Update) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	task, err := v.taskProv.GetTaskById(taskId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetTaskById for taskId: %v, %v", taskId, err)
+		return tgbotapi.Message{}, err
+	}
+	batch, err := v.taskProv.GetBatchById(task.BatchId.UUID.String())
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetBatchById for taskId: %v, %v", taskId, err)
+		return tgbotapi.Message{}, err
+	}
+
+	text := fmt.Sprintf("📬 Комната: *%s*\nЗадача: *%s*\n", room.Name, task.Name)
+	if task.Url != "" {
+		text += fmt.Sprintf("[Ссылка на задачу](%s)\n", task.Url)
+	}
+	text += fmt.Sprintf("\n⏳ Голосование до *%v*", batch.Deadline.Format("02.01 15:04"))
+
+	builder := new(tgbot2.MessageBuilder)
+	if u != nil {
+		builder.Message(u.GetChatId(), u.GetMessageId()).Edit(u.IsButton())
+		rates, err := v.rateProv.GetRatesByTaskId(taskId)
+		if err != nil {
+			lgr.Printf("[ERROR] unable to GetRatesByTaskId for taskId: %v, %v", taskId, err)
+			return tgbotapi.Message{}, err
+		}
+		for _, rate := range rates {
+			if rate.UserId == u.GetUserId() {
+				text += fmt.Sprintf("\n✅ Ваша оценка: *%s*, ее можно изменить до дедлайна", room.Scale.RateLabel(rate))
+			}
+		}
+	} else {
+		builder.NewMessage(userId)
+	}
+
+	var abstentions []model.Card
+	for _, card := range model.Abstentions {
+		if card.Kind != model.RateKindCoffee {
+			abstentions = append(abstentions, card)
+		}
+	}
+	builder.Text(text)
+	v.addRateKeyboard(builder, ActionAddBallotRate, room.Scale.Cards(), abstentions, taskId, roomId)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowBatchPublished tells the room chat which tasks were sent out and until when members can vote.
+func (v *View) ShowBatchPublished(room model.Room, batch model.Batch, tasks []model.Task, failed []model.Member) (tgbotapi.Message, error) {
+	if room.ChatId == 0 {
+		return tgbotapi.Message{}, nil
+	}
+	text := fmt.Sprintf("📬 Комната: *%s*\n\nЗадачи разосланы участникам в личные сообщения, голосование до *%v*:\n",
+		room.Name, batch.Deadline.Format("02.01 15:04"))
+	for _, task := range tasks {
+		text += fmt.Sprintf("- %s\n", task.Name)
+	}
+	if len(failed) > 0 {
+		text += "\n❗️ Не удалось отправить бюллетени, сперва напишите боту /start:\n"
+		for _, member := range failed {
+			text += fmt.Sprintf("- %s\n", memberLink(member))
+		}
+	}
+	builder := new(tgbot2.MessageBuilder).
+		NewMessage(room.ChatId).
+		Text(text)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
 func (v *View) ShowFinishedTaskView(taskId string, roomId string, rates []model.Rate, u *tgbot2.Update) (tgbotapi.Message, error) {
 	room, err := v.roomProv.GetRoomById(roomId)
 	if err != nil {
@@ -134,83 +315,155 @@ func (v *View) ShowFinishedTaskView(taskId string, roomId string, rates []model.
 	}
 	text += fmt.Sprintf("Задача: *%s*\n\n", task.Name)
 
-	users, err := v.roomProv.GetUsersByRoomId(roomId)
+	members, err := v.roomProv.GetMembersByRoomId(roomId)
 	if err != nil {
-		lgr.Printf("[ERROR] unable to GetUsersByRoomId for roomId: %d, %v", roomId, err)
+		lgr.Printf("[ERROR] unable to GetMembersByRoomId for roomId: %v, %v", roomId, err)
 		return tgbotapi.Message{}, err
 	}
 
-	var sumRates []int32
+	rounds, err := v.rateProv.GetRoundStats(taskId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetRoundStats for taskId: %v, %v", taskId, err)
+		return tgbotapi.Message{}, err
+	}
+	if len(rounds) == 0 {
+		lgr.Printf("[ERROR] no rounds for taskId: %v", taskId)
+		return tgbotapi.Message{}, ErrNoRounds
+	}
+	current := rounds[len(rounds)-1]
+
+	if current.Round > 1 {
+		text += fmt.Sprintf("Раунд: *%d*\n", current.Round)
+	}
+	if !room.Anonymous {
+		text += votesText(room.Scale, members, rates)
+	}
+	if current.Round > 1 {
+		previous := rounds[len(rounds)-2]
+		text += fmt.Sprintf("\nРаунд %d: %v", previous.Round, distribution(room.Scale, previous.Rates))
+		text += fmt.Sprintf("\nРаунд %d: %v\n", current.Round, distribution(room.Scale, current.Rates))
+	}
+	text += statsText(room.Scale, current.RateStats, members, room.Anonymous)
+
+	builder := new(tgbot2.MessageBuilder)
+	switch {
+	case task.MessageId != 0:
+		builder.EditMessageTextAndMarkup(task.ChatId, task.MessageId)
+	case u != nil:
+		builder.Message(u.GetChatId(), u.GetMessageId()).Edit(u.IsButton())
+	case room.ChatId == 0:
+		return tgbotapi.Message{}, nil
+	default:
+		builder.NewMessage(room.ChatId)
+	}
+	builder.Text(text)
+	if !room.IsAsync() {
+		finishBtn := v.createButton(ActionNextTask, map[string]string{"roomId": roomId})
+		builder.AddKeyboardRow().AddButton("🔜 Следующая задача", finishBtn.Id)
+	}
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// votesText lists every voting member with their vote, "❓" when they did not vote.
+func votesText(scale model.Scale, members []model.Member, rates []model.Rate) string {
 	userIdToRate := map[int64]model.Rate{}
 	for _, rate := range rates {
 		userIdToRate[rate.UserId] = rate
-		sumRates = append(sumRates, rate.Sum)
 	}
 
-	text += "Оценки: \n"
-	for _, user := range users {
-		rate := userIdToRate[user.UserId]
+	text := "Оценки: \n"
+	for _, member := range members {
+		if member.IsObserver() {
+			continue
+		}
 		rateEmoji := "❓"
-		if (rate != model.Rate{}) {
-			rateEmoji = strconv.Itoa(int(rate.Sum))
+		if rate, ok := userIdToRate[member.UserId]; ok {
+			rateEmoji = scale.RateLabel(rate)
 		}
-		text += fmt.Sprintf("%s - %s\n", rateEmoji, userLink(&user))
+		text += fmt.Sprintf("%s - %s\n", rateEmoji, memberLink(member))
 	}
-	text += fmt.Sprintf("\nМедиана - *%d*", calcMedian(sumRates))
+	return text
+}
 
-	mode, err := v.rateProv.GetModeByTaskId(taskId)
-	if err != nil {
-		lgr.Printf("[ERROR] unable to GetTaskById for taskId: %d, %v", taskId, err)
-		return tgbotapi.Message{}, err
-	}
-	text += fmt.Sprintf("\nМода - *%d*", mode)
+var consensusTitles = map[model.Consensus]string{
+	model.ConsensusFull: "🟢 полное",
+	model.ConsensusHigh: "🟡 близкое",
+	model.ConsensusLow:  "🟠 слабое",
+	model.ConsensusNone: "🔴 нет, обсудите",
+}
 
-	finishBtn := v.createButton(ActionNextTask, map[string]string{"roomId": roomId})
-	builder := new(tgbot2.MessageBuilder).
-		Message(u.GetChatId(), u.GetMessageId()).
-		Edit(u.IsButton()).
-		Text(text).AddKeyboardRow().AddButton("🔜 Следующая задача", finishBtn.Id)
+// statsText renders round statistics. Names of the lowest and highest voters are hidden in anonymous mode.
+func statsText(scale model.Scale, stats model.RateStats, members []model.Member, anonymous bool) string {
+	text := fmt.Sprintf("\n📊 Голосов: *%d*", stats.Votes)
+	if stats.Abstentions > 0 {
+		text += fmt.Sprintf(", воздержались: *%d*", stats.Abstentions)
+	}
+	text += "\n"
+	if stats.Votes == 0 {
+		return text
+	}
 
-	return logIfError(v.tg.Send(builder.Build()))
-}
+	text += fmt.Sprintf("Медиана - *%v*\n", scale.Label(stats.Median))
+	text += fmt.Sprintf("Мода - *%v*\n", scale.Label(stats.Mode))
+	text += fmt.Sprintf("Среднее - *%.1f*\n", stats.Mean)
+	text += fmt.Sprintf("Мин / Макс - *%v* / *%v*\n", scale.Label(stats.Min), scale.Label(stats.Max))
+	text += fmt.Sprintf("Стандартное отклонение - *%.1f*\n", stats.StdDev)
+	text += fmt.Sprintf("Согласие - %v\n", consensusTitles[stats.Consensus])
 
-func calcMedian(sums []int32) int32 {
-	if sums == nil || len(sums) == 0 {
-		return 0
+	text += "\n"
+	for _, bar := range stats.Histogram {
+		text += fmt.Sprintf("`%-3s` %v %d\n", bar.Label, strings.Repeat("█", bar.Count), bar.Count)
 	}
-	sort.Slice(sums, func(i, j int) bool { return sums[i] < sums[j] })
-	mNumber := len(sums) / 2
 
-	if isOdd(sums) {
-		return sums[mNumber]
+	if !anonymous && stats.Min != stats.Max {
+		text += fmt.Sprintf("\nМеньше всех (%v): %v", scale.Label(stats.Min), votersText(stats.LowestVoters, members))
+		text += fmt.Sprintf("\nБольше всех (%v): %v\n", scale.Label(stats.Max), votersText(stats.HighestVoters, members))
 	}
-	return (sums[mNumber-1] + sums[mNumber]) / 2
+	return text
 }
 
-func isOdd(sums []int32) bool {
-	if len(sums)%2 == 0 {
-		return false
+func votersText(userIds []int64, members []model.Member) string {
+	var names []string
+	for _, userId := range userIds {
+		for _, member := range members {
+			if member.UserId == userId {
+				names = append(names, memberLink(member))
+			}
+		}
 	}
-	return true
+	return strings.Join(names, ", ")
 }
 
-func (v *View) ShowTaskTime(taskId string, roomId string, u *tgbot2.Update) (tgbotapi.Message, error) {
-	task, err := v.taskProv.GetTaskById(taskId)
-	if err != nil {
-		lgr.Printf("[ERROR] unable to GetTaskById for taskId: %d, %v", taskId, err)
-		return tgbotapi.Message{}, err
+// distribution renders counts per card in scale order, abstentions last, e.g. "3 ×2, 5 ×1, ☕️ ×1".
+func distribution(scale model.Scale, rates []model.Rate) string {
+	counts := map[string]int{}
+	for _, rate := range rates {
+		counts[scale.RateLabel(rate)]++
 	}
-	room, err := v.roomProv.GetRoomById(roomId)
-	if err != nil {
-		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %d, %v", roomId, err)
-		return tgbotapi.Message{}, err
+
+	var parts []string
+	for _, card := range append(append([]model.Card{}, scale.Cards()...), model.Abstentions...) {
+		if counts[card.Label] > 0 {
+			parts = append(parts, fmt.Sprintf("%v ×%d", card.Label, counts[card.Label]))
+			delete(counts, card.Label)
+		}
 	}
-	if !task.Finished {
-		print(room.ChatId)
-		sub := task.CreatedDate.Sub(time.Now())
-		return v.WarnMessage(fmt.Sprintf("⏳ Осталось %v минут, %v секунд", sub.Minutes(), sub.Seconds()), u)
+	for label, count := range counts {
+		parts = append(parts, fmt.Sprintf("%v ×%d", label, count))
 	}
-	return tgbotapi.Message{}, nil
+	if len(parts) == 0 {
+		return "нет голосов"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func timeLeft(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
 }
 
 func (v *View) ShowTasks(roomId string, page int, u *tgbot2.Update) (tgbotapi.Message, error) {
@@ -236,7 +489,7 @@ func (v *View) ShowTasks(roomId string, page int, u *tgbot2.Update) (tgbotapi.Me
 		taskBtn := v.createButton(ActionShowTask, map[string]string{"taskId": task.Id.String(), "roomId": task.RoomId.String()})
 		finishedEmoji := "❌"
 		if task.Finished {
-			finishedEmoji = "✅ " + strconv.FormatInt(int64(task.Grade), 32)
+			finishedEmoji = "✅ " + room.Scale.Label(task.Grade)
 		}
 		builder.AddKeyboardRow().AddButton(fmt.Sprintf("%v %v", finishedEmoji, task.Name), taskBtn.Id)
 	}
@@ -253,25 +506,31 @@ func (v *View) ShowTasks(roomId string, page int, u *tgbot2.Update) (tgbotapi.Me
 	return logIfError(v.tg.Send(builder.Build()))
 }
 
-func (v *View) ShowTasksAfterFinishedRoom(roomId string, u *tgbot2.Update) (tgbotapi.Message, error) {
+// ShowTasksAfterFinishedRoom posts the summary of the finished planning to the room chat.
+// A room without a chat, e.g. after the bot was removed from it, is finished without the summary.
+func (v *View) ShowTasksAfterFinishedRoom(roomId string) (tgbotapi.Message, error) {
 	room, err := v.roomProv.GetRoomById(roomId)
 	if err != nil {
 		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %d, %v", roomId, err)
 		return tgbotapi.Message{}, err
 	}
-	tasks, err := v.taskProv.GetTasksByRoomIdAndPagination(room.Id.String(), 0, math.MaxInt64)
+	if room.ChatId == 0 {
+		return tgbotapi.Message{}, nil
+	}
+	tasks, err := v.taskProv.GetAllTasksByRoomId(room.Id.String())
 	if err != nil {
 		lgr.Printf("[ERROR] unable to GetTasksByRoomId for roomId: %d, %v", roomId, err)
 		return tgbotapi.Message{}, err
 	}
-	if tasks == nil {
-		_, _ = v.ErrorMessage(u, "❗️ Не найдены задачи")
-		return tgbotapi.Message{}, nil
-	}
 
-	text := fmt.Sprintf("*❗ Планирование завершено*\n\nКомната: *%v*\nЗадачи:\n", room.Name)
+	text := fmt.Sprintf("*❗ Планирование завершено*\n\nКомната: *%v*\n", room.Name)
+	if len(tasks) == 0 {
+		text += "Задач не было\n"
+	} else {
+		text += "Задачи:\n"
+	}
 	for _, task := range tasks {
-		text += fmt.Sprintf("- *%v* %v\n", task.Grade, task.Name)
+		text += fmt.Sprintf("- *%v* %v\n", room.Scale.Label(task.Grade), task.Name)
 	}
 
 	builder := new(tgbot2.MessageBuilder).
@@ -280,7 +539,8 @@ func (v *View) ShowTasksAfterFinishedRoom(roomId string, u *tgbot2.Update) (tgbo
 	return logIfError(v.tg.Send(builder.Build()))
 }
 
-func (v *View) ShowSetTaskGrade(taskId, roomId string, u *tgbot2.Update) (tgbotapi.Message, error) {
+// ShowSetTaskGrade asks the facilitator who revealed the task for the final grade, the owner is asked when userId is 0.
+func (v *View) ShowSetTaskGrade(taskId, roomId string, userId int64) (tgbotapi.Message, error) {
 	room, err := v.roomProv.GetRoomById(roomId)
 	if err != nil {
 		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %d, $v", roomId, err)
@@ -295,28 +555,41 @@ func (v *View) ShowSetTaskGrade(taskId, roomId string, u *tgbot2.Update) (tgbota
 	}
 	text += fmt.Sprintf("Завершена оценка по задаче: *%s*\n", task.Name)
 
-	sumRates, err := v.rateProv.GetRatesSums(taskId)
+	rounds, err := v.rateProv.GetRoundStats(taskId)
 	if err != nil {
-		lgr.Printf("[ERROR] unable to GetRatesByTaskId for taskId: %d, $v", taskId, err)
+		lgr.Printf("[ERROR] unable to GetRoundStats for taskId: %v, %v", taskId, err)
 		return tgbotapi.Message{}, err
 	}
+	if len(rounds) == 0 {
+		lgr.Printf("[ERROR] no rounds for taskId: %v", taskId)
+		return tgbotapi.Message{}, ErrNoRounds
+	}
+	current := rounds[len(rounds)-1]
 
-	if sumRates != nil {
-		text += fmt.Sprintf("\nМедиана - *%d*", calcMedian(sumRates))
+	members, err := v.roomProv.GetMembersByRoomId(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetMembersByRoomId for roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	if room.Anonymous {
+		text += "\n🕶 Видно только вам\n" + votesText(room.Scale, members, current.Rates)
+	}
 
-		mode, err := v.rateProv.GetModeByTaskId(taskId)
-		if err != nil {
-			lgr.Fatalf("[ERROR] unable to GetRatesByTaskId for taskId: %d, $v", taskId, err)
-			return tgbotapi.Message{}, err
+	if current.Rates != nil {
+		if current.Round > 1 {
+			text += fmt.Sprintf("\nРаунд: *%d*", current.Round)
 		}
-		text += fmt.Sprintf("\nМода - *%d*", mode)
+		text += statsText(room.Scale, current.RateStats, members, false)
 	}
 
 	finishRateBtn := v.createButton(ActionFinishTaskRate, map[string]string{"roomId": roomId, "taskId": taskId})
 	revoteRateBtn := v.createButton(ActionRevoteTaskRate, map[string]string{"roomId": roomId, "taskId": taskId})
 
+	if userId == 0 {
+		userId = room.UserId
+	}
 	builder := new(tgbot2.MessageBuilder).
-		NewMessage(room.UserId).
+		NewMessage(userId).
 		Text(text).
 		AddKeyboardRow().AddButton("Ввести итоговую оценку", finishRateBtn.Id).
 		AddKeyboardRow().AddButton("Переголосовать", revoteRateBtn.Id)
@@ -324,3 +597,104 @@ func (v *View) ShowSetTaskGrade(taskId, roomId string, u *tgbot2.Update) (tgbota
 	return logIfError(v.tg.Send(builder.Build()))
 
 }
+
+// ShowCoffeeAlert tells the facilitators of the room that a voter asks for a break.
+func (v *View) ShowCoffeeAlert(taskId, roomId string, u *tgbot2.Update) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %v, %v", roomId, err)
+		return
+	}
+	task, err := v.taskProv.GetTaskById(taskId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetTaskById for taskId: %v, %v", taskId, err)
+		return
+	}
+	members, err := v.roomProv.GetMembersByRoomId(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetMembersByRoomId for roomId: %v, %v", roomId, err)
+		return
+	}
+
+	user := u.GetUser()
+	text := fmt.Sprintf("☕️ %v просит перерыв\n\nКомната: *%s*\nЗадача: *%s*", userLink(&user),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, task.Name))
+	for _, member := range members {
+		if member.UserId == user.UserId || member.Role != model.RoleFacilitator && member.UserId != room.UserId {
+			continue
+		}
+		builder := new(tgbot2.MessageBuilder).
+			NewMessage(member.UserId).
+			Text(text)
+		_, _ = logIfError(v.tg.Send(builder.Build()))
+	}
+}
+
+// ShowResultChart sends the vote distribution of the current round with the final grade as a picture to the chat of the room.
+func (v *View) ShowResultChart(taskId, roomId string) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	if room.ChatId == 0 {
+		return tgbotapi.Message{}, nil
+	}
+	task, err := v.taskProv.GetTaskById(taskId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetTaskById for taskId: %v, %v", taskId, err)
+		return tgbotapi.Message{}, err
+	}
+	rates, err := v.rateProv.GetRatesByTaskId(taskId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetRatesByTaskId for taskId: %v, %v", taskId, err)
+		return tgbotapi.Message{}, err
+	}
+
+	chart, err := renderChart(room.Scale, rates, task.Grade)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to render chart for taskId: %v, %v", taskId, err)
+		return tgbotapi.Message{}, err
+	}
+
+	text := fmt.Sprintf("📊 Задача: *%s*\nИтоговая оценка: *%s*", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, task.Name),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Scale.Label(task.Grade)))
+	builder := new(tgbot2.MessageBuilder).
+		NewMessage(room.ChatId).
+		Text(text).
+		Photo("chart.png", chart)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowTaskGraded tells the other facilitators of the room who set the final grade of the task.
+func (v *View) ShowTaskGraded(taskId, roomId string, grader tgbot2.User) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetRoomById for roomId: %v, %v", roomId, err)
+		return
+	}
+	task, err := v.taskProv.GetTaskById(taskId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetTaskById for taskId: %v, %v", taskId, err)
+		return
+	}
+	members, err := v.roomProv.GetMembersByRoomId(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to GetMembersByRoomId for roomId: %v, %v", roomId, err)
+		return
+	}
+
+	text := fmt.Sprintf("⭐️ %v выставил итоговую оценку *%s*\n\nКомната: *%s*\nЗадача: *%s*",
+		userLink(&grader), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Scale.Label(task.Grade)),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, task.Name))
+	for _, member := range members {
+		if member.UserId == grader.UserId || member.Role != model.RoleFacilitator && member.UserId != room.UserId {
+			continue
+		}
+		builder := new(tgbot2.MessageBuilder).
+			NewMessage(member.UserId).
+			Text(text)
+		_, _ = logIfError(v.tg.Send(builder.Build()))
+	}
+}
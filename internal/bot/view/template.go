@@ -0,0 +1,55 @@
+package view
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gotestbot/internal/service/model"
+	"gotestbot/sdk/tgbot"
+)
+
+// AddCloneTasks asks whether unfinished tasks of the source room move to the clone.
+func (v *View) AddCloneTasks(roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	yesBtn := v.createButton(ActionCloneRoom, map[string]string{"roomId": roomId, "tasks": "yes"})
+	noBtn := v.createButton(ActionCloneRoom, map[string]string{"roomId": roomId, "tasks": "no"})
+
+	builder := new(tgbot.MessageBuilder).
+		NewMessage(u.GetUserId()).
+		Text("Перенести незавершенные задачи в новую комнату?").
+		AddKeyboardRow().AddButton("✅ Перенести", yesBtn.Id).AddButton("Не переносить", noBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// ShowRoomCloned tells the group that planning continues in a new room with the same members.
+func (v *View) ShowRoomCloned(room model.Room, sourceName string) (tgbotapi.Message, error) {
+	builder := new(tgbot.MessageBuilder).
+		NewMessage(room.ChatId).
+		Text(fmt.Sprintf("🧬 Создана комната *%v* на основе *%v*, участники перенесены",
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, room.Name), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, sourceName)))
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+func (v *View) ShowTemplates(prefix string, templates []model.RoomTemplate, u *tgbot.Update) (tgbotapi.Message, error) {
+	text := "📑 Шаблоны комнат\n\nШаблон хранит чат, участников и настройки комнаты. " +
+		"Сохранить комнату как шаблон можно в меню комнаты."
+	if len(templates) == 0 {
+		text += "\n\nШаблонов пока нет"
+	}
+
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text(prefix + text)
+	for _, template := range templates {
+		useBtn := v.createButton(ActionUseTemplate, map[string]string{"templateId": template.Id.String()})
+		deleteBtn := v.createButton(ActionDeleteTemplate, map[string]string{"templateId": template.Id.String()})
+		builder.AddKeyboardRow().
+			AddButton(fmt.Sprintf("▶️ %v (%v)", template.Name, template.Scale.Title()), useBtn.Id).
+			AddButton("❌", deleteBtn.Id)
+	}
+	backBtn := v.createButton(ActionStart, nil)
+	builder.AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
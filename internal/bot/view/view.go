@@ -5,27 +5,32 @@ import (
 	"github.com/go-pkgz/lgr"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/google/uuid"
+	"gotestbot/internal/export"
 	"gotestbot/internal/service/model"
 	"gotestbot/sdk/tgbot"
+	"net/url"
 	"strconv"
 )
 
 type RoomProvider interface {
 	GetRoomById(roomId string) (model.Room, error)
 	GetUsersByRoomId(roomId string) ([]tgbot.User, error)
+	GetMembersByRoomId(roomId string) ([]model.Member, error)
+	GetTracker(roomId string) (*model.TrackerConfig, error)
 }
 
 type TaskProvider interface {
 	GetTaskById(taskId string) (model.Task, error)
 	GetTasksByRoomId(roomId string) ([]model.Task, error)
+	GetAllTasksByRoomId(roomId string) ([]model.Task, error)
 	GetTasksByRoomIdAndPagination(roomId string, offset, limit int) ([]model.Task, error)
+	GetBatchById(batchId string) (model.Batch, error)
 }
 
 type RateProvider interface {
 	GetRatesByTaskId(taskId string) ([]model.Rate, error)
-	GetRatesSums(taskId string) ([]int32, error)
+	GetRoundStats(taskId string) ([]model.RoundStats, error)
 	UpsertRate(rate model.Rate) error
-	GetModeByTaskId(taskId string) (int32, error)
 }
 
 type UserProvider interface {
@@ -40,28 +45,35 @@ type View struct {
 	rateProv RateProvider
 
 	tg *tgbot.Bot
+
+	// webAppUrl is the page of the Web App voting board, the board is not offered when it is empty
+	webAppUrl string
 }
 
-func NewView(btnProv tgbot.ChatProvider, userProv UserProvider, roomProv RoomProvider, taskProv TaskProvider, rateProv RateProvider, tg *tgbot.Bot) *View {
+func NewView(btnProv tgbot.ChatProvider, userProv UserProvider, roomProv RoomProvider, taskProv TaskProvider, rateProv RateProvider,
+	tg *tgbot.Bot, webAppUrl string) *View {
 	return &View{
-		chatProv: btnProv,
-		userProv: userProv,
-		roomProv: roomProv,
-		taskProv: taskProv,
-		rateProv: rateProv,
-		tg:       tg}
+		chatProv:  btnProv,
+		userProv:  userProv,
+		roomProv:  roomProv,
+		taskProv:  taskProv,
+		rateProv:  rateProv,
+		tg:        tg,
+		webAppUrl: webAppUrl}
 }
 
 func (v *View) StartView(u *tgbot.Update) (tgbotapi.Message, error) {
 
 	crtBtn := v.createButton(ActionCreateRoom, nil)
 	showBtn := v.createButton(ActionShowRooms, nil)
+	templatesBtn := v.createButton(ActionShowTemplates, nil)
 
 	msg := new(tgbot.MessageBuilder).
 		Message(u.GetChatId(), u.GetMessageId()).
 		Edit(u.IsButton()).
 		Text("Добро пожаловать! \nЭто *PlanPokerBot*. Выберите одно из предоложенных действий").
 		AddKeyboardRow().AddButton("Создать комнату", crtBtn.Id).
+		AddKeyboardRow().AddButton("Создать из шаблона", templatesBtn.Id).
 		AddKeyboardRow().AddButton("Просмотреть комнаты", showBtn.Id).
 		Build()
 
@@ -69,15 +81,11 @@ func (v *View) StartView(u *tgbot.Update) (tgbotapi.Message, error) {
 }
 
 func (v *View) ShowRoomView(prefix, roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
-	users, err := v.roomProv.GetUsersByRoomId(roomId)
+	roomMembers, err := v.roomProv.GetMembersByRoomId(roomId)
 	if err != nil {
-		lgr.Printf("[ERROR] unable to get users by roomId: %d", roomId)
-	}
-
-	var members string
-	for _, user := range users {
-		members += "- " + userLink(&user) + "\n"
+		lgr.Printf("[ERROR] unable to get members by roomId: %v", roomId)
 	}
+	members := membersText(roomMembers)
 	room, err := v.roomProv.GetRoomById(roomId)
 	if err != nil {
 		lgr.Printf("[ERROR] unable to get room by roomId: %d", roomId)
@@ -86,22 +94,106 @@ func (v *View) ShowRoomView(prefix, roomId string, u *tgbot.Update) (tgbotapi.Me
 	builder := new(tgbot.MessageBuilder).
 		Message(u.GetUserId(), u.GetMessageId()).
 		Edit(u.IsButton()).
-		Text(prefix + fmt.Sprintf("Комната - *%v*\n🗓 %v \n\nУчастники:\n%v", room.Name, room.CreatedDate.Format("02 January 2006"), members))
+		Text(prefix + fmt.Sprintf("Комната - *%v*\n🗓 %v \n🃏 %v\n\n%v", room.Name, room.CreatedDate.Format("02 January 2006"), room.Scale.Title(), members))
 
 	backBtn := v.createButton(ActionStart, nil)
 	addTaskBtn := v.createButton(ActionCreateTask, map[string]string{"roomId": roomId})
+	bulkTaskBtn := v.createButton(ActionBulkCreateTasks, map[string]string{"roomId": roomId})
 	tasksBtn := v.createButton(ActionShowTasks, map[string]string{"roomId": roomId, "page": "0"})
-	nextTaskBtn := v.createButton(ActionNextTask, map[string]string{"roomId": roomId})
 	finishRmBtn := v.createButton(ActionFinishRoom, map[string]string{"roomId": roomId})
+	settingsBtn := v.createButton(ActionShowRoomSettings, map[string]string{"roomId": roomId})
 
-	builder.AddKeyboardRow().AddButton("➕ Добавить задачу", addTaskBtn.Id).
+	builder.AddKeyboardRow().AddButton("➕ Добавить задачу", addTaskBtn.Id).AddButton("📋 Добавить списком", bulkTaskBtn.Id).
 		AddKeyboardRow().AddButtonSwitch("📢 Отправить в чат", room.Name).
-		AddKeyboardRow().AddButton("🗂 Задачи", tasksBtn.Id).AddButton("📤 Следующая задача", nextTaskBtn.Id).
+		AddKeyboardRow().AddButton("🗂 Задачи", tasksBtn.Id)
+	if room.IsAsync() {
+		batchBtn := v.createButton(ActionPublishBatch, map[string]string{"roomId": roomId})
+		builder.AddButton("📬 Разослать задачи", batchBtn.Id)
+	} else {
+		nextTaskBtn := v.createButton(ActionNextTask, map[string]string{"roomId": roomId})
+		builder.AddButton("📤 Следующая задача", nextTaskBtn.Id)
+	}
+	exportBtn := v.createButton(ActionExportRoom, map[string]string{"roomId": roomId})
+	cloneBtn := v.createButton(ActionCloneRoom, map[string]string{"roomId": roomId})
+	templateBtn := v.createButton(ActionSaveTemplate, map[string]string{"roomId": roomId})
+	builder.AddKeyboardRow().AddButton("📊 Экспорт результатов", exportBtn.Id).
+		AddKeyboardRow().AddButton("🧬 Клонировать", cloneBtn.Id).AddButton("💾 Сохранить как шаблон", templateBtn.Id)
+	if v.webAppUrl != "" {
+		boardBtn := v.createButton(ActionOpenBoard, map[string]string{"roomId": roomId})
+		builder.AddKeyboardRow().AddButton("🗳 Доска голосования", boardBtn.Id)
+	}
+	adminBtn := v.createButton(ActionShowRoomAdmin, map[string]string{"roomId": roomId})
+	builder.AddKeyboardRow().AddButton("⚙️ Настройки", settingsBtn.Id).AddButton("🛠 Управление", adminBtn.Id).
 		AddKeyboardRow().AddButton("🏁 Завершить планирование", finishRmBtn.Id).
 		AddKeyboardRow().AddButton("Назад", backBtn.Id)
 	return logIfError(v.tg.Send(builder.Build()))
 }
 
+// ShowBoardButton sends a new message with the button opening the Web App board of the room,
+// Web App buttons cannot be added to an edited message.
+func (v *View) ShowBoardButton(roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	room, err := v.roomProv.GetRoomById(roomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get room by roomId: %v, %v", roomId, err)
+		return tgbotapi.Message{}, err
+	}
+	link, err := url.Parse(v.webAppUrl)
+	if err != nil {
+		lgr.Printf("[ERROR] invalid web app url %q, %v", v.webAppUrl, err)
+		return tgbotapi.Message{}, err
+	}
+	query := link.Query()
+	query.Set("roomId", roomId)
+	link.RawQuery = query.Encode()
+
+	msg := new(tgbot.MessageBuilder).
+		NewMessage(u.GetUserId()).
+		Text(fmt.Sprintf("🗳 Доска голосования комнаты *%v*", room.Name)).
+		AddKeyboardRow().AddButtonWebApp("Открыть доску", link.String()).
+		Build()
+
+	return logIfError(v.tg.Send(msg))
+}
+
+// ShowExportFormats offers the formats of the room results file.
+func (v *View) ShowExportFormats(roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
+	builder := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), u.GetMessageId()).
+		Edit(u.IsButton()).
+		Text("📊 Экспорт результатов\n\nВ файле будут задачи со ссылками, итоговые оценки, медиана и мода, " +
+			"число раундов и голоса участников последнего раунда. Выберите формат:")
+	for _, format := range export.Formats {
+		formatBtn := v.createButton(ActionExportRoom, map[string]string{"roomId": roomId, "format": string(format)})
+		builder.AddKeyboardRow().AddButton(format.Title(), formatBtn.Id)
+	}
+	backBtn := v.createButton(ActionShowRoom, map[string]string{"roomId": roomId})
+	builder.AddKeyboardRow().AddButton("Назад", backBtn.Id)
+
+	return logIfError(v.tg.Send(builder.Build()))
+}
+
+// SendDocument sends a file to the chat as a new message.
+func (v *View) SendDocument(chatId int64, fileName string, data []byte, caption string) (tgbotapi.Message, error) {
+	msg := new(tgbot.MessageBuilder).
+		NewMessage(chatId).
+		Document(fileName, data).
+		Text(caption).
+		Build()
+
+	return logIfError(v.tg.Send(msg))
+}
+
+// maxImportFileSize limits documents downloaded for import.
+const maxImportFileSize = 5 << 20
+
+func (v *View) DownloadFile(fileId string) ([]byte, error) {
+	data, err := v.tg.DownloadFile(fileId, maxImportFileSize)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to download document, %v", err)
+	}
+	return data, err
+}
+
 func (v *View) ErrorMessage(u *tgbot.Update, text string) (tgbotapi.Message, error) {
 	c := &tgbotapi.CallbackConfig{
 		CallbackQueryID: u.CallbackQuery.ID,
@@ -120,6 +212,17 @@ func (v *View) WarnMessage(text string, u *tgbot.Update) (tgbotapi.Message, erro
 	return logIfError(v.tg.Send(c))
 }
 
+// ShowApiToken sends a freshly issued API token. The token is not stored, so it is shown only once.
+func (v *View) ShowApiToken(token string, u *tgbot.Update) (tgbotapi.Message, error) {
+	msg := new(tgbot.MessageBuilder).
+		Message(u.GetUserId(), 0).
+		Text(fmt.Sprintf("🔑 Ваш токен API:\n\n`%v`\n\nПередавайте его в заголовке `Authorization: Bearer <токен>`. "+
+			"Токен показывается один раз, предыдущий токен больше не действует.", token)).
+		Build()
+
+	return logIfError(v.tg.Send(msg))
+}
+
 func (v *View) ErrorMessageText(text string, u *tgbot.Update) (tgbotapi.Message, error) {
 	msg := new(tgbot.MessageBuilder).
 		Message(u.GetUserId(), u.GetMessageId()).
@@ -144,36 +247,29 @@ func (v *View) ShowRoomsInline(rooms []model.Room, u *tgbot.Update) (tgbotapi.Me
 	inlineRequest := tgbot.NewInlineRequest(u.GetInlineId())
 	for _, room := range rooms {
 		joinBtn := v.createButton(ActionJoinRoom, map[string]string{"roomId": room.Id.String()})
+		observeBtn := v.createButton(ActionObserveRoom, map[string]string{"roomId": room.Id.String()})
 
-		users, err := v.roomProv.GetUsersByRoomId(room.Id.String())
+		roomMembers, err := v.roomProv.GetMembersByRoomId(room.Id.String())
 		if err != nil {
-			lgr.Printf("[ERROR] unable to get users by roomId: %d", room.Id.String())
-		}
-
-		var members string
-		for _, user := range users {
-			members += "- " + userLink(&user) + "\n"
+			lgr.Printf("[ERROR] unable to get members by roomId: %v", room.Id.String())
 		}
+		members := membersText(roomMembers)
 
 		inlineRequest.AddArticle(uuid.NewString(),
 			room.Name, "Статус",
-			fmt.Sprintf("Комната - *%v*\n🗓 %v \n\nУчастники:\n%v", room.Name, room.CreatedDate.Format("02 January 2006"), members)).
-			AddKeyboardRow().AddButton("Присоединиться", joinBtn.Id)
+			fmt.Sprintf("Комната - *%v*\n🗓 %v \n\n%v", room.Name, room.CreatedDate.Format("02 January 2006"), members)).
+			AddKeyboardRow().AddButton("Присоединиться", joinBtn.Id).AddButton("👀 Наблюдать", observeBtn.Id)
 	}
 
 	return logIfError(v.tg.Send(inlineRequest.Build()))
 }
 
 func (v *View) ShowRoomViewInline(roomId string, u *tgbot.Update) (tgbotapi.Message, error) {
-	users, err := v.roomProv.GetUsersByRoomId(roomId)
+	roomMembers, err := v.roomProv.GetMembersByRoomId(roomId)
 	if err != nil {
-		lgr.Printf("[ERROR] unable to get users by roomId: %d", roomId)
-	}
-
-	var members string
-	for _, user := range users {
-		members += "- " + userLink(&user) + "\n"
+		lgr.Printf("[ERROR] unable to get members by roomId: %v", roomId)
 	}
+	members := membersText(roomMembers)
 	room, err := v.roomProv.GetRoomById(roomId)
 	if err != nil {
 		lgr.Printf("[ERROR] unable to get room by roomId: %d", roomId)
@@ -182,11 +278,12 @@ func (v *View) ShowRoomViewInline(roomId string, u *tgbot.Update) (tgbotapi.Mess
 	builder := new(tgbot.MessageBuilder).
 		InlineId(u.GetInlineId()).
 		Edit(u.IsButton()).
-		Text(fmt.Sprintf("Комната - *%v*\n🗓 %v \n\nУчастники:\n%v", room.Name, room.CreatedDate.Format("02 January 2006"), members))
+		Text(fmt.Sprintf("Комната - *%v*\n🗓 %v \n\n%v", room.Name, room.CreatedDate.Format("02 January 2006"), members))
 
 	joinBtn := v.createButton(ActionJoinRoom, map[string]string{"roomId": room.Id.String()})
+	observeBtn := v.createButton(ActionObserveRoom, map[string]string{"roomId": room.Id.String()})
 
-	builder.AddKeyboardRow().AddButton("Присоединиться", joinBtn.Id).Build()
+	builder.AddKeyboardRow().AddButton("Присоединиться", joinBtn.Id).AddButton("👀 Наблюдать", observeBtn.Id)
 	send, err := v.tg.Send(builder.Build())
 	return logIfError(send, err)
 }
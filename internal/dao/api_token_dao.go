@@ -0,0 +1,33 @@
+package dao
+
+import (
+	"time"
+)
+
+// ReplaceApiToken stores the token hash of the user and revokes the previous tokens.
+func (r *Repository) ReplaceApiToken(userId int64, tokenHash string, date time.Time) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM api_token WHERE user_id = $1`, userId); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`INSERT INTO api_token(token_hash, user_id, created_date) VALUES ($1, $2, $3)`,
+		tokenHash, userId, date); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseApiToken returns the owner of the token and remembers when it was used. sql.ErrNoRows means the token is unknown.
+func (r *Repository) UseApiToken(tokenHash string, date time.Time) (int64, error) {
+	var userId int64
+	row := r.db.QueryRow(`UPDATE api_token SET last_used_date = $2 WHERE token_hash = $1 RETURNING user_id`, tokenHash, date)
+	if err := row.Scan(&userId); err != nil {
+		return 0, err
+	}
+	return userId, nil
+}
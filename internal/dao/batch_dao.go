@@ -0,0 +1,126 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+)
+
+// SaveBatch stores the batch and moves into it every not finished task of the room that is not in a batch yet.
+// Nothing is stored when the room has no such tasks.
+func (r *Repository) SaveBatch(batch model.Batch) ([]model.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO batch(id, room_id, deadline, status, created_date)
+				VALUES (:id, :room_id, :deadline, :status, :created_date)`
+	if _, err = tx.NamedExec(insert, batch); err != nil {
+		return nil, errors.Wrapf(err, "unable to save batch, roomId: %v", batch.RoomId)
+	}
+
+	rows, err := tx.Queryx(`UPDATE task SET batch_id = $1, published_date = $2
+							WHERE room_id = $3 AND finished IS FALSE AND batch_id IS NULL
+							RETURNING *`, batch.Id, batch.CreatedDate, batch.RoomId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to add tasks to batch, batchId: %v", batch.Id)
+	}
+	var tasks []model.Task
+	for rows.Next() {
+		t := model.Task{}
+		if err = rows.StructScan(&t); err != nil {
+			rows.Close()
+			return nil, errors.Wrapf(err, "unable to add tasks to batch, batchId: %v", batch.Id)
+		}
+		tasks = append(tasks, t)
+	}
+	rows.Close()
+
+	// without tasks the batch is rolled back so no empty batch stays open
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *Repository) GetBatchById(batchId string) (model.Batch, error) {
+	row := r.db.QueryRowx("SELECT * FROM batch WHERE id = $1", batchId)
+
+	batch := model.Batch{}
+	if err := row.StructScan(&batch); err != nil {
+		return model.Batch{}, errors.Wrapf(err, "unable to get batch, batchId: %v", batchId)
+	}
+	return batch, nil
+}
+
+func (r *Repository) GetOpenBatchesByRoomId(roomId string) ([]model.Batch, error) {
+	rows, err := r.db.Queryx(`SELECT * FROM batch WHERE room_id = $1 AND status = 'OPEN' ORDER BY created_date`, roomId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []model.Batch
+	for rows.Next() {
+		b := model.Batch{}
+		if err = rows.StructScan(&b); err != nil {
+			return []model.Batch{}, errors.Wrapf(err, "unable to get batches, roomId: %v", roomId)
+		}
+		batches = append(batches, b)
+	}
+	return batches, nil
+}
+
+func (r *Repository) GetTasksByBatchId(batchId string) ([]model.Task, error) {
+	rows, err := r.db.Queryx(`SELECT * FROM task WHERE batch_id = $1 ORDER BY created_date`, batchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []model.Task
+	for rows.Next() {
+		t := model.Task{}
+		if err = rows.StructScan(&t); err != nil {
+			return []model.Task{}, errors.Wrapf(err, "unable to get tasks, batchId: %v", batchId)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// CountMissingBallots returns how many ballots of voting members are still empty across all tasks of the batch.
+func (r *Repository) CountMissingBallots(batchId string) (int, error) {
+	var missing int
+	row := r.db.QueryRow(`SELECT count(1)
+						  FROM task t
+								   JOIN room_member rm ON rm.room_id = t.room_id AND rm.role <> 'OBSERVER'
+						  WHERE t.batch_id = $1
+							AND NOT EXISTS(SELECT 1
+										   FROM rate r
+										   WHERE r.task_id = t.id
+											 AND r.user_id = rm.user_id
+											 AND r.kind <> 'COFFEE'
+											 AND r.round = t.round)`, batchId)
+	if err := row.Scan(&missing); err != nil {
+		return 0, err
+	}
+	return missing, nil
+}
+
+// RevealBatch closes the batch. It reports false when the batch was already revealed, so only one caller shows the results.
+func (r *Repository) RevealBatch(batchId string) (bool, error) {
+	res, err := r.db.Exec(`UPDATE batch SET status = 'REVEALED' WHERE id = $1 AND status = 'OPEN'`, batchId)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,82 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+	"time"
+)
+
+func (r *Repository) SaveJob(job model.Job) error {
+	insert := `INSERT INTO job(id, kind, payload, status, run_at, attempts, max_attempts, created_date)
+				VALUES (:id, :kind, :payload, :status, :run_at, :attempts, :max_attempts, :created_date)`
+
+	if _, err := r.db.NamedExec(insert, job); err != nil {
+		return errors.Wrapf(err, "unable to save job, kind: %v", job.Kind)
+	}
+	return nil
+}
+
+// ClaimJobs moves due jobs to RUNNING in one statement, so concurrent instances never get the same job. A claimed job
+// is leased until lockedUntil, a RUNNING job with an expired lease was interrupted by a crash and is claimed again
+// while it has attempts left.
+func (r *Repository) ClaimJobs(now, lockedUntil time.Time, limit int) ([]model.Job, error) {
+	rows, err := r.db.Queryx(`UPDATE job SET status = 'RUNNING', attempts = attempts + 1, locked_until = $2
+								WHERE id IN (SELECT id FROM job
+											 WHERE status = 'NEW' AND run_at <= $1
+											    OR status = 'RUNNING' AND locked_until < $1 AND attempts < max_attempts
+											 ORDER BY run_at
+											 LIMIT $3 FOR UPDATE SKIP LOCKED)
+								RETURNING *`, now, lockedUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []model.Job
+	for rows.Next() {
+		j := model.Job{}
+		if err = rows.StructScan(&j); err != nil {
+			return []model.Job{}, errors.Wrap(err, "unable to claim jobs")
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// FailExpiredJobs fails RUNNING jobs whose lease expired after the last attempt, they are not claimed again.
+func (r *Repository) FailExpiredJobs(now time.Time) (int64, error) {
+	res, err := r.db.Exec(`UPDATE job SET status = 'FAILED', last_error = 'lease expired', finished_date = $1
+							WHERE status = 'RUNNING' AND locked_until < $1 AND attempts >= max_attempts`, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *Repository) FinishJob(jobId string, status model.JobStatus, lastError string, date time.Time) error {
+	_, err := r.db.Exec(`UPDATE job SET status = $2, last_error = $3, finished_date = $4 WHERE id = $1;`,
+		jobId, status, lastError, date)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CancelRoomJobs cancels jobs of the kinds waiting to run for the room.
+func (r *Repository) CancelRoomJobs(roomId string, kinds []string, date time.Time) (int64, error) {
+	res, err := r.db.Exec(`UPDATE job SET status = 'CANCELED', finished_date = $3
+							WHERE status = 'NEW' AND payload ->> 'roomId' = $1 AND kind = ANY($2)`, roomId, kinds, date)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to cancel jobs, roomId: %v", roomId)
+	}
+	return res.RowsAffected()
+}
+
+func (r *Repository) RetryJob(jobId string, runAt time.Time, lastError string) error {
+	_, err := r.db.Exec(`UPDATE job SET status = 'NEW', run_at = $2, last_error = $3 WHERE id = $1;`,
+		jobId, runAt, lastError)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package dao
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/pkg/errors"
+)
+
+// Notify sends the payload to every session listening on the channel, including other instances of the bot.
+func (r *Repository) Notify(channel, payload string) error {
+	_, err := r.db.Exec(`SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
+
+// Listen holds a connection listening on the channel and passes payloads to the handler until ctx is done
+// or the connection fails. The connection stops listening before it returns to the pool.
+func (r *Repository) Listen(ctx context.Context, channel string, handler func(payload string)) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get connection to listen on %v", channel)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.Errorf("unable to listen on %v, unexpected driver %T", channel, driverConn)
+		}
+		pgConn := stdConn.Conn()
+		defer func() {
+			if !pgConn.IsClosed() {
+				_, _ = pgConn.Exec(context.Background(), "UNLISTEN *")
+			}
+		}()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return errors.Wrapf(err, "unable to listen on %v", channel)
+		}
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			handler(notification.Payload)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+)
+
+func (r *Repository) SetNameRoom(roomId string, name string) error {
+	_, err := r.db.Exec(`UPDATE room SET name = $2 WHERE id = $1;`, roomId, name)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteRoomMember removes the member from the room together with the votes on unfinished tasks,
+// votes of revealed tasks stay in the history.
+func (r *Repository) DeleteRoomMember(userId int64, roomId string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM rate ra USING task t
+						 WHERE t.id = ra.task_id AND t.room_id = $2 AND t.finished IS FALSE AND ra.user_id = $1`, userId, roomId); err != nil {
+		return errors.Wrapf(err, "unable to delete pending rates, userId: %v, roomId: %v", userId, roomId)
+	}
+	if _, err = tx.Exec(`DELETE FROM room_member WHERE user_id = $1 AND room_id = $2`, userId, roomId); err != nil {
+		return errors.Wrapf(err, "unable to delete member, userId: %v, roomId: %v", userId, roomId)
+	}
+	return tx.Commit()
+}
+
+// SetOwnerRoom hands the room over to the member, the new owner becomes a facilitator
+// and the previous owner stays in the room as a facilitator.
+func (r *Repository) SetOwnerRoom(roomId string, userId int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`UPDATE room SET user_id = $2 WHERE id = $1`, roomId, userId); err != nil {
+		return errors.Wrapf(err, "unable to set owner, roomId: %v", roomId)
+	}
+	if _, err = tx.Exec(ownerFacilitator, userId, roomId); err != nil {
+		return errors.Wrapf(err, "unable to make owner facilitator, roomId: %v", roomId)
+	}
+	return tx.Commit()
+}
+
+// SetRoleRoomMember changes the role of the member, unlike SaveRoomMember it can take the facilitator role away.
+func (r *Repository) SetRoleRoomMember(userId int64, roomId string, role model.MemberRole) error {
+	_, err := r.db.Exec(`UPDATE room_member SET role = $3 WHERE user_id = $1 AND room_id = $2`, userId, roomId, role)
+	if err != nil {
+		return err
+	}
+	return nil
+}
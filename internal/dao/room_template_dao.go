@@ -0,0 +1,136 @@
+package dao
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+	"time"
+)
+
+const insertFullRoom = `INSERT INTO room(id, name, user_id, status, chat_id, scale, timer_seconds, reveal_policy, reveal_quorum, anonymous, mode, auto_join, present_hours, created_date)
+						VALUES (:id, :name, :user_id, :status, :chat_id, :scale, :timer_seconds, :reveal_policy, :reveal_quorum, :anonymous, :mode, :auto_join, :present_hours, :created_date)`
+
+// ownerFacilitator makes the owner of a new room its facilitator whatever role the owner had in the source.
+const ownerFacilitator = `INSERT INTO room_member(user_id, room_id, role) VALUES ($1, $2, 'FACILITATOR')
+						  ON CONFLICT (user_id, room_id) DO UPDATE SET role = excluded.role`
+
+// SaveClonedRoom saves the room with the owner and the tracker of the source room in one transaction,
+// the tracker token is copied only when withToken is set. Unfinished tasks of the source are copied
+// as new tasks when withTasks is set. Other members are added by the service, so member.joined is emitted.
+func (r *Repository) SaveClonedRoom(room model.Room, sourceRoomId string, withTasks, withToken bool) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.NamedExec(insertFullRoom, room); err != nil {
+		return errors.Wrapf(err, "unable to save cloned room, sourceRoomId: %v", sourceRoomId)
+	}
+	if _, err = tx.Exec(ownerFacilitator, room.UserId, room.Id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`INSERT INTO room_tracker(room_id, kind, base_url, user_name, token, points_field, created_date)
+						 SELECT $2, kind, base_url, user_name, CASE WHEN $4 THEN token ELSE '' END, points_field, $3
+						 FROM room_tracker WHERE room_id = $1`,
+		sourceRoomId, room.Id, room.CreatedDate, withToken); err != nil {
+		return errors.Wrapf(err, "unable to copy tracker, sourceRoomId: %v", sourceRoomId)
+	}
+	if withTasks {
+		if err = copyUnfinishedTasks(tx, sourceRoomId, room); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// copyUnfinishedTasks adds unfinished tasks of the source room to the room keeping their order.
+// The source room keeps its tasks and their votes.
+func copyUnfinishedTasks(tx *sqlx.Tx, sourceRoomId string, room model.Room) error {
+	var tasks []model.Task
+	if err := tx.Select(&tasks, `SELECT * FROM task WHERE room_id = $1 AND finished IS FALSE ORDER BY created_date`, sourceRoomId); err != nil {
+		return errors.Wrapf(err, "unable to get unfinished tasks, roomId: %v", sourceRoomId)
+	}
+	insert := `INSERT INTO task(id, name, url, room_id, finished, created_date, grade) VALUES (:id, :name, :url, :room_id, :finished, :created_date, :grade)`
+	for i, task := range tasks {
+		clone := model.Task{
+			Id:          uuid.New(),
+			Name:        task.Name,
+			Url:         task.Url,
+			RoomId:      room.Id,
+			CreatedDate: room.CreatedDate.Add(time.Duration(i) * time.Millisecond),
+		}
+		if _, err := tx.NamedExec(insert, clone); err != nil {
+			return errors.Wrapf(err, "unable to copy task %q", task.Name)
+		}
+	}
+	return nil
+}
+
+// SaveTemplate saves the template with the members of the source room.
+func (r *Repository) SaveTemplate(template model.RoomTemplate, sourceRoomId string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO room_template(id, user_id, name, chat_id, scale, timer_seconds, reveal_policy, reveal_quorum, anonymous, mode, auto_join, present_hours, created_date)
+				VALUES (:id, :user_id, :name, :chat_id, :scale, :timer_seconds, :reveal_policy, :reveal_quorum, :anonymous, :mode, :auto_join, :present_hours, :created_date)`
+	if _, err = tx.NamedExec(insert, template); err != nil {
+		return errors.Wrapf(err, "unable to save template, sourceRoomId: %v", sourceRoomId)
+	}
+	if _, err = tx.Exec(`INSERT INTO room_template_member(template_id, user_id, role)
+						 SELECT $2, user_id, role FROM room_member WHERE room_id = $1`, sourceRoomId, template.Id); err != nil {
+		return errors.Wrapf(err, "unable to save template members, sourceRoomId: %v", sourceRoomId)
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) GetTemplateById(templateId string) (model.RoomTemplate, error) {
+	template := model.RoomTemplate{}
+	if err := r.db.QueryRowx(`SELECT * FROM room_template WHERE id = $1`, templateId).StructScan(&template); err != nil {
+		return model.RoomTemplate{}, errors.Wrapf(err, "unable to get template, templateId: %v", templateId)
+	}
+	return template, nil
+}
+
+func (r *Repository) GetTemplateMembers(templateId string) ([]model.Member, error) {
+	var members []model.Member
+	if err := r.db.Select(&members, `SELECT user_id, role FROM room_template_member WHERE template_id = $1`, templateId); err != nil {
+		return nil, errors.Wrapf(err, "unable to get template members, templateId: %v", templateId)
+	}
+	return members, nil
+}
+
+func (r *Repository) GetTemplatesByUserId(userId int64) ([]model.RoomTemplate, error) {
+	var templates []model.RoomTemplate
+	if err := r.db.Select(&templates, `SELECT * FROM room_template WHERE user_id = $1 ORDER BY name`, userId); err != nil {
+		return nil, errors.Wrapf(err, "unable to get templates, userId: %v", userId)
+	}
+	return templates, nil
+}
+
+func (r *Repository) DeleteTemplate(templateId string) error {
+	_, err := r.db.Exec(`DELETE FROM room_template WHERE id = $1`, templateId)
+	return err
+}
+
+// SaveRoomFromTemplate saves the room with the owner in one transaction,
+// members of the template are added by the service, so member.joined is emitted.
+func (r *Repository) SaveRoomFromTemplate(room model.Room, templateId string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.NamedExec(insertFullRoom, room); err != nil {
+		return errors.Wrapf(err, "unable to save room from template, templateId: %v", templateId)
+	}
+	if _, err = tx.Exec(ownerFacilitator, room.UserId, room.Id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"gotestbot/internal/service/model"
 	"gotestbot/sdk/tgbot"
+	"time"
 )
 
 func (r *Repository) SaveTask(task model.Task) error {
@@ -16,8 +17,40 @@ func (r *Repository) SaveTask(task model.Task) error {
 	return nil
 }
 
-func (r *Repository) SetFinishedTask(taskId string) error {
-	_, err := r.db.Exec(`UPDATE task SET finished = TRUE WHERE id = $1;`, taskId)
+// SaveTasks stores all tasks in one transaction, nothing is saved if any of them fails.
+func (r *Repository) SaveTasks(tasks []model.Task) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO task(id, name, url, room_id, finished, created_date, grade) VALUES (:id, :name, :url, :room_id, :finished, :created_date, :grade)`
+	for _, task := range tasks {
+		if _, err = tx.NamedExec(insert, task); err != nil {
+			return errors.Wrapf(err, "unable to save task %q", task.Name)
+		}
+	}
+	return tx.Commit()
+}
+
+// SetFinishedTask finishes the current round. It reports false when the task was already finished,
+// so only one of concurrent reveals shows the results.
+func (r *Repository) SetFinishedTask(taskId string) (bool, error) {
+	res, err := r.db.Exec(`UPDATE task SET finished = TRUE WHERE id = $1 AND finished IS FALSE;`, taskId)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *Repository) SetPublishedTask(taskId string, chatId int64, messageId int, date time.Time) error {
+	_, err := r.db.Exec(`UPDATE task SET chat_id = $2, message_id = $3, published_date = $4 WHERE id = $1;`,
+		taskId, chatId, messageId, date)
 	if err != nil {
 		return err
 	}
@@ -60,6 +93,25 @@ func (r *Repository) GetTasksByRoomId(roomId string, offset, limit int) ([]model
 	return tasks, nil
 }
 
+// GetAllTasksByRoomId returns every task of the room, for summaries and exports that need the whole room at once.
+func (r *Repository) GetAllTasksByRoomId(roomId string) ([]model.Task, error) {
+	rows, err := r.db.Queryx(`SELECT * FROM task WHERE room_id = $1 ORDER BY created_date`, roomId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []model.Task
+	for rows.Next() {
+		t := model.Task{}
+		if err = rows.StructScan(&t); err != nil {
+			return []model.Task{}, errors.Wrapf(err, "unable to get tasks, roomId: %v", roomId)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
 func (r *Repository) GetNextNotFinishedTask(roomId string) (model.Task, error) {
 	const query = `SELECT * FROM task 
 				   WHERE finished IS FALSE AND room_id = $1 
@@ -73,22 +125,50 @@ func (r *Repository) GetNextNotFinishedTask(roomId string) (model.Task, error) {
 	return task, nil
 }
 
-func (r *Repository) TaskFinished(taskId string) (bool, error) {
-	var finished bool
-	row := r.db.QueryRow(`SELECT(SELECT count(1) = (SELECT count(1)
-									  FROM room_member rm
-									  WHERE rm.room_id = (SELECT t.room_id FROM task t WHERE t.id = $1 )) 
-							FROM rate r
-							WHERE task_id = $1)`, taskId)
-	err := row.Scan(&finished)
-	if err != nil {
-		return false, err
+// CountTaskVotes returns how many voting members voted in the current round, not counting coffee breaks, and how many members the room has.
+func (r *Repository) CountTaskVotes(taskId string) (voted int, members int, err error) {
+	row := r.db.QueryRow(`SELECT (SELECT count(1)
+								  FROM rate r
+								  WHERE r.task_id = $1 AND r.kind <> 'COFFEE'
+									AND r.round = (SELECT t.round FROM task t WHERE t.id = $1)),
+								 (SELECT count(1)
+								  FROM room_member rm
+								  WHERE rm.room_id = (SELECT t.room_id FROM task t WHERE t.id = $1)
+									AND rm.role <> 'OBSERVER')`, taskId)
+	if err = row.Scan(&voted, &members); err != nil {
+		return 0, 0, err
+	}
+	return voted, members, nil
+}
+
+// CountPresentMembers returns members who voted on other tasks of the room since the given time,
+// and how many of them have not voted on the task yet.
+func (r *Repository) CountPresentMembers(taskId string, since time.Time) (present int, missing int, err error) {
+	row := r.db.QueryRow(`WITH present AS (SELECT DISTINCT r.user_id
+										   FROM rate r
+													JOIN task t ON t.id = r.task_id
+													JOIN room_member rm ON rm.user_id = r.user_id AND rm.room_id = t.room_id
+																		   AND rm.role <> 'OBSERVER'
+										   WHERE t.room_id = (SELECT room_id FROM task WHERE id = $1)
+											 AND t.id <> $1
+											 AND r.created_date >= $2)
+						  SELECT count(1),
+								 count(1) FILTER (WHERE NOT EXISTS(SELECT 1
+																   FROM rate cur
+																   WHERE cur.task_id = $1
+																	 AND cur.user_id = p.user_id
+																	 AND cur.kind <> 'COFFEE'
+																	 AND cur.round = (SELECT round FROM task WHERE id = $1)))
+						  FROM present p`, taskId, since)
+	if err = row.Scan(&present, &missing); err != nil {
+		return 0, 0, err
 	}
-	return finished, nil
+	return present, missing, nil
 }
 
 func (r *Repository) SaveRoom(room model.Room) error {
-	insert := `INSERT INTO room(id, name, user_id, status, chat_id, created_date) VALUES (:id, :name, :user_id, :status, :chat_id, :created_date)`
+	insert := `INSERT INTO room(id, name, user_id, status, chat_id, scale, timer_seconds, reveal_policy, reveal_quorum, created_date) 
+				VALUES (:id, :name, :user_id, :status, :chat_id, :scale, :timer_seconds, :reveal_policy, :reveal_quorum, :created_date)`
 
 	if _, err := r.db.NamedExec(insert, room); err != nil {
 		return err
@@ -114,6 +194,86 @@ func (r *Repository) SetChatIdRoom(roomId string, chatId int64) error {
 	return nil
 }
 
+// UnbindChatRooms detaches not finished rooms from the chat and returns them.
+func (r *Repository) UnbindChatRooms(chatId int64) ([]model.Room, error) {
+	var rooms []model.Room
+	if err := r.db.Select(&rooms, `UPDATE room SET chat_id = 0 WHERE chat_id = $1 AND status <> 'FINISHED' RETURNING *`, chatId); err != nil {
+		return nil, errors.Wrapf(err, "unable to unbind rooms, chatId: %v", chatId)
+	}
+	return rooms, nil
+}
+
+// MigrateChatRooms moves rooms, templates and unfinished tasks of a group to the supergroup it was upgraded to
+// and returns the moved rooms.
+func (r *Repository) MigrateChatRooms(chatId, newChatId int64) ([]model.Room, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rooms []model.Room
+	if err = tx.Select(&rooms, `UPDATE room SET chat_id = $2 WHERE chat_id = $1 RETURNING *`, chatId, newChatId); err != nil {
+		return nil, errors.Wrapf(err, "unable to migrate rooms, chatId: %v", chatId)
+	}
+	if _, err = tx.Exec(`UPDATE room_template SET chat_id = $2 WHERE chat_id = $1`, chatId, newChatId); err != nil {
+		return nil, errors.Wrapf(err, "unable to migrate templates, chatId: %v", chatId)
+	}
+	// messages of the old group cannot be edited anymore, the bot publishes the current tasks again in the supergroup
+	if _, err = tx.Exec(`UPDATE task SET chat_id = $2, message_id = 0 WHERE chat_id = $1 AND finished IS FALSE`, chatId, newChatId); err != nil {
+		return nil, errors.Wrapf(err, "unable to migrate tasks, chatId: %v", chatId)
+	}
+	return rooms, tx.Commit()
+}
+
+func (r *Repository) SetTimerRoom(roomId string, seconds int) error {
+	_, err := r.db.Exec(`UPDATE room SET timer_seconds = $2 WHERE id = $1;`, roomId, seconds)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) SetRevealPolicyRoom(roomId string, policy model.RevealPolicy, quorum int) error {
+	_, err := r.db.Exec(`UPDATE room SET reveal_policy = $2, reveal_quorum = $3 WHERE id = $1;`, roomId, policy, quorum)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) SetPresentHoursRoom(roomId string, hours int) error {
+	_, err := r.db.Exec(`UPDATE room SET present_hours = $2 WHERE id = $1;`, roomId, hours)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) SetAnonymousRoom(roomId string, anonymous bool) error {
+	_, err := r.db.Exec(`UPDATE room SET anonymous = $2 WHERE id = $1;`, roomId, anonymous)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) SetModeRoom(roomId string, mode model.RoomMode) error {
+	_, err := r.db.Exec(`UPDATE room SET mode = $2 WHERE id = $1;`, roomId, mode)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) SetAutoJoinRoom(roomId string, autoJoin bool) error {
+	_, err := r.db.Exec(`UPDATE room SET auto_join = $2 WHERE id = $1;`, roomId, autoJoin)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (r *Repository) SetStatusRoom(status model.RoomStatus, roomId string) error {
 	_, err := r.db.Exec(`UPDATE room SET status = $1 WHERE id = $2;`, status, roomId)
 	if err != nil {
@@ -142,6 +302,62 @@ func (r *Repository) GetRoomsByNameAndUserId(roomName string, userId int64) ([]m
 	return rooms, nil
 }
 
+// GetRoomsByMemberId returns rooms the user owns or is a member of, newest first.
+func (r *Repository) GetRoomsByMemberId(userId int64) ([]model.Room, error) {
+	query := `SELECT r.* FROM room r
+			  WHERE r.user_id = $1
+			     OR EXISTS(SELECT 1 FROM room_member rm WHERE rm.room_id = r.id AND rm.user_id = $1)
+			  ORDER BY r.created_date DESC`
+	rows, err := r.db.Queryx(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []model.Room
+	for rows.Next() {
+		r := model.Room{}
+		if err = rows.StructScan(&r); err != nil {
+			return []model.Room{}, errors.Wrapf(err, "unable to get rooms, userId: %v", userId)
+		}
+		rooms = append(rooms, r)
+	}
+	return rooms, nil
+}
+
+// GetActiveRoomsByChatId returns not finished rooms bound to the chat.
+func (r *Repository) GetActiveRoomsByChatId(chatId int64) ([]model.Room, error) {
+	var rooms []model.Room
+	if err := r.db.Select(&rooms, `SELECT * FROM room WHERE chat_id = $1 AND status <> 'FINISHED' ORDER BY created_date DESC`, chatId); err != nil {
+		return nil, errors.Wrapf(err, "unable to get rooms, chatId: %v", chatId)
+	}
+	return rooms, nil
+}
+
+// GetManagedRoomsByUserId returns not finished rooms the user owns or facilitates, newest first.
+func (r *Repository) GetManagedRoomsByUserId(userId int64) ([]model.Room, error) {
+	query := `SELECT r.* FROM room r
+			  WHERE r.status <> 'FINISHED'
+			    AND (r.user_id = $1 OR EXISTS(SELECT 1 FROM room_member rm
+											  WHERE rm.room_id = r.id AND rm.user_id = $1 AND rm.role = 'FACILITATOR'))
+			  ORDER BY r.created_date DESC`
+	rows, err := r.db.Queryx(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []model.Room
+	for rows.Next() {
+		r := model.Room{}
+		if err = rows.StructScan(&r); err != nil {
+			return []model.Room{}, errors.Wrapf(err, "unable to get rooms, userId: %v", userId)
+		}
+		rooms = append(rooms, r)
+	}
+	return rooms, nil
+}
+
 func (r *Repository) GetUsersByRoomId(roomId string) ([]tgbot.User, error) {
 	rows, err := r.db.Queryx(`SELECT p.* FROM profile p 
     							JOIN room_member rm ON rm.user_id = p.user_id 
@@ -155,7 +371,7 @@ func (r *Repository) GetUsersByRoomId(roomId string) ([]tgbot.User, error) {
 	for rows.Next() {
 		u := tgbot.User{}
 		if err = rows.StructScan(&u); err != nil {
-			return []tgbot.User{}, errors.Wrapf(err, "unable to get users, roomId: %d", roomId)
+			return []tgbot.User{}, errors.Wrapf(err, "unable to get users, roomId: %v", roomId)
 		}
 		users = append(users, u)
 	}
@@ -163,6 +379,41 @@ func (r *Repository) GetUsersByRoomId(roomId string) ([]tgbot.User, error) {
 	return users, nil
 }
 
+func (r *Repository) GetMembersByRoomId(roomId string) ([]model.Member, error) {
+	rows, err := r.db.Queryx(`SELECT p.user_id, p.user_name, p.display_name, rm.role FROM profile p 
+    							JOIN room_member rm ON rm.user_id = p.user_id 
+								WHERE rm.room_id = $1`, roomId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []model.Member
+	for rows.Next() {
+		m := model.Member{}
+		if err = rows.StructScan(&m); err != nil {
+			return []model.Member{}, errors.Wrapf(err, "unable to get members, roomId: %v", roomId)
+		}
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+func (r *Repository) GetMember(userId int64, roomId string) (*model.Member, error) {
+	row := r.db.QueryRowx(`SELECT p.user_id, p.user_name, p.display_name, rm.role FROM profile p 
+    							JOIN room_member rm ON rm.user_id = p.user_id 
+								WHERE rm.room_id = $1 AND rm.user_id = $2`, roomId, userId)
+	member := model.Member{}
+	err := row.StructScan(&member)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "unable to get member, userId: %v, roomId: %v", userId, roomId)
+	}
+	return &member, nil
+}
+
 func (r *Repository) GetRoom(roomId string) ([]tgbot.User, error) {
 	rows, err := r.db.Queryx(`SELECT p.* FROM profile p 
     							JOIN room_member rm ON rm.user_id = p.user_id 
@@ -176,7 +427,7 @@ func (r *Repository) GetRoom(roomId string) ([]tgbot.User, error) {
 	for rows.Next() {
 		u := tgbot.User{}
 		if err = rows.StructScan(&u); err != nil {
-			return []tgbot.User{}, errors.Wrapf(err, "unable to get users, roomId: %d", roomId)
+			return []tgbot.User{}, errors.Wrapf(err, "unable to get users, roomId: %v", roomId)
 		}
 		users = append(users, u)
 	}
@@ -184,17 +435,21 @@ func (r *Repository) GetRoom(roomId string) ([]tgbot.User, error) {
 	return users, nil
 }
 
-func (r *Repository) SaveRoomMember(userId int64, roomId string) error {
-	insert := `INSERT INTO room_member(user_id, room_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+// SaveRoomMember adds the user to the room or switches between voter and observer. Facilitators keep their role.
+func (r *Repository) SaveRoomMember(userId int64, roomId string, role model.MemberRole) error {
+	insert := `INSERT INTO room_member(user_id, room_id, role) VALUES ($1, $2, $3) 
+				ON CONFLICT (user_id, room_id) DO UPDATE SET role = excluded.role 
+				WHERE room_member.role <> 'FACILITATOR'`
 
-	if _, err := r.db.Exec(insert, userId, roomId); err != nil {
+	if _, err := r.db.Exec(insert, userId, roomId, role); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (r *Repository) SaveRate(rate model.Rate) error {
-	insert := `INSERT INTO rate(id, task_id, user_id, sum, created_date) VALUES (:id, :task_id, :user_id, :sum, :created_date)`
+	insert := `INSERT INTO rate(id, task_id, user_id, sum, kind, round, created_date)
+				VALUES (:id, :task_id, :user_id, :sum, :kind, (SELECT t.round FROM task t WHERE t.id = :task_id), :created_date)`
 
 	if _, err := r.db.NamedExec(insert, rate); err != nil {
 		return err
@@ -203,15 +458,17 @@ func (r *Repository) SaveRate(rate model.Rate) error {
 }
 
 func (r *Repository) UpdateRate(rateId string, rate model.Rate) error {
-	insert := `UPDATE rate SET sum = $2 WHERE id = $1`
-	if _, err := r.db.Query(insert, rateId, rate.Sum); err != nil {
+	insert := `UPDATE rate SET sum = $2, kind = $3 WHERE id = $1`
+	if _, err := r.db.Exec(insert, rateId, rate.Sum, rate.Kind); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (r *Repository) GetRateByUserAndTaskId(userId int64, taskId string) (*model.Rate, error) {
-	row := r.db.QueryRowx("SELECT rate.* FROM rate WHERE task_id = $1 AND user_id = $2", taskId, userId)
+	row := r.db.QueryRowx(`SELECT rate.* FROM rate 
+								WHERE task_id = $1 AND user_id = $2
+								  AND round = (SELECT t.round FROM task t WHERE t.id = $1)`, taskId, userId)
 	rate := model.Rate{}
 	err := row.StructScan(&rate)
 	if err == sql.ErrNoRows {
@@ -224,7 +481,29 @@ func (r *Repository) GetRateByUserAndTaskId(userId int64, taskId string) (*model
 
 func (r *Repository) GetRatesByTaskId(taskId string) ([]model.Rate, error) {
 	rows, err := r.db.Queryx(`SELECT r.* FROM rate  r 
-								WHERE r.task_id = $1`, taskId)
+								WHERE r.task_id = $1
+								  AND r.round = (SELECT t.round FROM task t WHERE t.id = $1)`, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []model.Rate
+	for rows.Next() {
+		r := model.Rate{}
+		if err = rows.StructScan(&r); err != nil {
+			return []model.Rate{}, errors.Wrapf(err, "unable to get rates, taskId: %v", taskId)
+		}
+		rates = append(rates, r)
+	}
+
+	return rates, nil
+}
+
+func (r *Repository) GetAllRoundsRatesByTaskId(taskId string) ([]model.Rate, error) {
+	rows, err := r.db.Queryx(`SELECT r.* FROM rate r 
+								WHERE r.task_id = $1
+								ORDER BY r.round, r.created_date`, taskId)
 	if err != nil {
 		return nil, err
 	}
@@ -242,9 +521,10 @@ func (r *Repository) GetRatesByTaskId(taskId string) ([]model.Rate, error) {
 	return rates, nil
 }
 
-func (r *Repository) DelRatesByTaskId(taskId string) error {
-	query := "DELETE FROM rate WHERE task_id = $1"
-	if _, err := r.db.Query(query, taskId); err != nil {
+// StartNewRoundTask opens the next round. The task leaves its revealed batch, so it can be sent in a new one.
+func (r *Repository) StartNewRoundTask(taskId string) error {
+	_, err := r.db.Exec(`UPDATE task SET round = round + 1, finished = FALSE, batch_id = NULL WHERE id = $1;`, taskId)
+	if err != nil {
 		return err
 	}
 	return nil
@@ -252,10 +532,28 @@ func (r *Repository) DelRatesByTaskId(taskId string) error {
 
 func (r *Repository) GetModeByTaskId(taskId string) (int32, error) {
 	var mode int32
-	row := r.db.QueryRow("SELECT mode() within GROUP (order by sum) FROM rate WHERE task_id = $1", taskId)
+	row := r.db.QueryRow(`SELECT coalesce(mode() within GROUP (order by sum), 0) FROM rate 
+								WHERE task_id = $1 AND kind = 'ESTIMATE'
+								  AND round = (SELECT t.round FROM task t WHERE t.id = $1)`, taskId)
 	err := row.Scan(&mode)
 	if err != nil {
 		return 0, err
 	}
 	return mode, nil
 }
+
+// GetCurrentTask returns the task published last in the room, nil if nothing was published yet.
+func (r *Repository) GetCurrentTask(roomId string) (*model.Task, error) {
+	const query = `SELECT * FROM task
+				   WHERE room_id = $1 AND published_date IS NOT NULL
+				   ORDER BY published_date DESC LIMIT 1`
+	task := model.Task{}
+	err := r.db.QueryRowx(query, roomId).StructScan(&task)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get current task, roomId: %v", roomId)
+	}
+	return &task, nil
+}
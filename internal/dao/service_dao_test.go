@@ -0,0 +1,137 @@
+package dao
+
+import (
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" //for db migration
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"gotestbot/internal/service/model"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestRepository connects to the database given by TEST_PG_DSN and migrates it, tests are skipped without it.
+func openTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	dsn := os.Getenv("TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("TEST_PG_DSN is not set")
+	}
+	m, err := migrate.New("file://../../db/migrations", dsn)
+	if err != nil {
+		t.Fatalf("unable to init migrations: %v", err)
+	}
+	if err = m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	db, err := sqlx.Connect("pgx", dsn)
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return NewRepository(db)
+}
+
+var testRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// testChatId returns a random group chat id, so runs on the same database do not see each other's rows.
+func testChatId() int64 {
+	return -testRand.Int63n(1_000_000_000) - 1
+}
+
+func saveTestRoom(t *testing.T, r *Repository, chatId int64, status model.RoomStatus) model.Room {
+	t.Helper()
+	room := model.Room{Id: uuid.New(), Name: "room", UserId: 1, Status: status, ChatId: chatId,
+		Scale: model.ScaleFibonacci, RevealPolicy: model.RevealAll, CreatedDate: time.Now()}
+	if err := r.SaveRoom(room); err != nil {
+		t.Fatalf("unable to save room: %v", err)
+	}
+	return room
+}
+
+func saveTestTask(t *testing.T, r *Repository, room model.Room, finished bool, messageId int) model.Task {
+	t.Helper()
+	task := model.Task{Id: uuid.New(), Name: "task", RoomId: room.Id, Finished: finished, CreatedDate: time.Now()}
+	if err := r.SaveTask(task); err != nil {
+		t.Fatalf("unable to save task: %v", err)
+	}
+	if err := r.SetPublishedTask(task.Id.String(), room.ChatId, messageId, time.Now()); err != nil {
+		t.Fatalf("unable to publish task: %v", err)
+	}
+	return task
+}
+
+func TestMigrateChatRooms(t *testing.T) {
+	r := openTestRepository(t)
+	chatId, newChatId := testChatId(), testChatId()
+	room := saveTestRoom(t, r, chatId, model.New)
+	open := saveTestTask(t, r, room, false, 10)
+	done := saveTestTask(t, r, room, true, 11)
+
+	rooms, err := r.MigrateChatRooms(chatId, newChatId)
+	if err != nil {
+		t.Fatalf("MigrateChatRooms: %v", err)
+	}
+	if len(rooms) != 1 || rooms[0].Id != room.Id || rooms[0].ChatId != newChatId {
+		t.Errorf("migrated rooms = %v, want only %v", rooms, room.Id)
+	}
+	if got, _ := r.GetRoomById(room.Id.String()); got.ChatId != newChatId {
+		t.Errorf("room chat = %v, want %v", got.ChatId, newChatId)
+	}
+	if got, _ := r.GetTaskById(open.Id.String()); got.ChatId != newChatId || got.MessageId != 0 {
+		t.Errorf("unfinished task chat, message = %v, %v, want %v, 0", got.ChatId, got.MessageId, newChatId)
+	}
+	if got, _ := r.GetTaskById(done.Id.String()); got.ChatId != chatId || got.MessageId != 11 {
+		t.Errorf("finished task chat, message = %v, %v, want %v, 11", got.ChatId, got.MessageId, chatId)
+	}
+}
+
+func TestUnbindChatRooms(t *testing.T) {
+	r := openTestRepository(t)
+	chatId := testChatId()
+	active := saveTestRoom(t, r, chatId, model.New)
+	finished := saveTestRoom(t, r, chatId, model.Finished)
+
+	rooms, err := r.UnbindChatRooms(chatId)
+	if err != nil {
+		t.Fatalf("UnbindChatRooms: %v", err)
+	}
+	if len(rooms) != 1 || rooms[0].Id != active.Id {
+		t.Fatalf("unbound rooms = %v, want only %v", rooms, active.Id)
+	}
+	if got, _ := r.GetRoomById(active.Id.String()); got.ChatId != 0 {
+		t.Errorf("active room chat = %v, want 0", got.ChatId)
+	}
+	if got, _ := r.GetRoomById(finished.Id.String()); got.ChatId != chatId {
+		t.Errorf("finished room chat = %v, want %v", got.ChatId, chatId)
+	}
+}
+
+func TestCancelRoomJobs(t *testing.T) {
+	r := openTestRepository(t)
+	roomId := uuid.NewString()
+	timer := model.Job{Id: uuid.New(), Kind: "TASK_TIMER", Payload: model.JobPayload{"roomId": roomId},
+		Status: model.JobNew, RunAt: time.Now().Add(time.Hour), MaxAttempts: 1, CreatedDate: time.Now()}
+	other := timer
+	other.Id, other.Kind = uuid.New(), "TRACKER_WRITE_BACK"
+	for _, job := range []model.Job{timer, other} {
+		if err := r.SaveJob(job); err != nil {
+			t.Fatalf("unable to save job: %v", err)
+		}
+	}
+
+	canceled, err := r.CancelRoomJobs(roomId, []string{"TASK_TIMER", "BATCH_DEADLINE"}, time.Now())
+	if err != nil {
+		t.Fatalf("CancelRoomJobs: %v", err)
+	}
+	if canceled != 1 {
+		t.Errorf("canceled = %d, want 1", canceled)
+	}
+	var status model.JobStatus
+	if err = r.db.Get(&status, `SELECT status FROM job WHERE id = $1`, other.Id); err != nil || status != model.JobNew {
+		t.Errorf("other job status = %v, %v, want %v", status, err, model.JobNew)
+	}
+}
@@ -0,0 +1,44 @@
+package dao
+
+import (
+	"database/sql"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+)
+
+func (r *Repository) SaveTracker(conf model.TrackerConfig) error {
+	insert := `INSERT INTO room_tracker(room_id, kind, base_url, user_name, token, points_field, created_date)
+				VALUES (:room_id, :kind, :base_url, :user_name, :token, :points_field, :created_date)
+				ON CONFLICT (room_id) DO UPDATE SET kind         = excluded.kind,
+													base_url     = excluded.base_url,
+													user_name    = excluded.user_name,
+													token        = excluded.token,
+													points_field = excluded.points_field`
+
+	if _, err := r.db.NamedExec(insert, conf); err != nil {
+		return errors.Wrapf(err, "unable to save tracker, roomId: %v", conf.RoomId)
+	}
+	return nil
+}
+
+// GetTracker returns nil when the room is not bound to a tracker.
+func (r *Repository) GetTracker(roomId string) (*model.TrackerConfig, error) {
+	row := r.db.QueryRowx("SELECT * FROM room_tracker WHERE room_id = $1", roomId)
+
+	conf := model.TrackerConfig{}
+	err := row.StructScan(&conf)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "unable to get tracker, roomId: %v", roomId)
+	}
+	return &conf, nil
+}
+
+func (r *Repository) DeleteTracker(roomId string) error {
+	_, err := r.db.Exec(`DELETE FROM room_tracker WHERE room_id = $1`, roomId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
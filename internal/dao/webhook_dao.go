@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+	"time"
+)
+
+func (r *Repository) SaveWebhook(hook model.Webhook) error {
+	insert := `INSERT INTO webhook(id, room_id, url, secret, created_date) VALUES (:id, :room_id, :url, :secret, :created_date)`
+
+	if _, err := r.db.NamedExec(insert, hook); err != nil {
+		return errors.Wrapf(err, "unable to save webhook, roomId: %v", hook.RoomId.UUID)
+	}
+	return nil
+}
+
+func (r *Repository) GetWebhookById(webhookId string) (model.Webhook, error) {
+	row := r.db.QueryRowx("SELECT * FROM webhook WHERE id = $1", webhookId)
+
+	hook := model.Webhook{}
+	if err := row.StructScan(&hook); err != nil {
+		return model.Webhook{}, errors.Wrapf(err, "unable to get webhook, webhookId: %v", webhookId)
+	}
+	return hook, nil
+}
+
+func (r *Repository) GetWebhooksByRoomId(roomId string) ([]model.Webhook, error) {
+	rows, err := r.db.Queryx(`SELECT * FROM webhook WHERE room_id = $1 ORDER BY created_date`, roomId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []model.Webhook
+	for rows.Next() {
+		h := model.Webhook{}
+		if err = rows.StructScan(&h); err != nil {
+			return []model.Webhook{}, errors.Wrapf(err, "unable to get webhooks, roomId: %v", roomId)
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, nil
+}
+
+func (r *Repository) DeleteWebhook(webhookId string) error {
+	_, err := r.db.Exec(`DELETE FROM webhook WHERE id = $1`, webhookId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) SaveWebhookDelivery(delivery model.WebhookDelivery) error {
+	insert := `INSERT INTO webhook_delivery(id, webhook_id, url, event, payload, status, created_date)
+				VALUES (:id, :webhook_id, :url, :event, :payload, :status, :created_date)`
+
+	if _, err := r.db.NamedExec(insert, delivery); err != nil {
+		return errors.Wrapf(err, "unable to save webhook delivery, event: %v", delivery.Event)
+	}
+	return nil
+}
+
+func (r *Repository) GetWebhookDeliveryById(deliveryId string) (model.WebhookDelivery, error) {
+	row := r.db.QueryRowx("SELECT * FROM webhook_delivery WHERE id = $1", deliveryId)
+
+	delivery := model.WebhookDelivery{}
+	if err := row.StructScan(&delivery); err != nil {
+		return model.WebhookDelivery{}, errors.Wrapf(err, "unable to get webhook delivery, deliveryId: %v", deliveryId)
+	}
+	return delivery, nil
+}
+
+// SetWebhookDeliveryAttempt records the outcome of one attempt. deliveredDate is nil while the delivery is not done.
+func (r *Repository) SetWebhookDeliveryAttempt(deliveryId string, status model.DeliveryStatus, responseCode int,
+	lastError string, deliveredDate *time.Time) error {
+	_, err := r.db.Exec(`UPDATE webhook_delivery 
+						 SET status = $2, attempts = attempts + 1, response_code = $3, last_error = $4, delivered_date = $5 
+						 WHERE id = $1`, deliveryId, status, responseCode, lastError, deliveredDate)
+	if err != nil {
+		return err
+	}
+	return nil
+}
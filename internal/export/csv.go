@@ -0,0 +1,41 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// utf8Bom makes Excel read the file as UTF-8 instead of the system code page.
+var utf8Bom = []byte{0xEF, 0xBB, 0xBF}
+
+func writeCsv(table Table) ([]byte, error) {
+	buf := bytes.NewBuffer(append([]byte{}, utf8Bom...))
+	w := csv.NewWriter(buf)
+	if err := w.Write(neutralize(table.Header)); err != nil {
+		return nil, err
+	}
+	for _, row := range table.Rows {
+		if err := w.Write(neutralize(row)); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// neutralize keeps spreadsheets from running task names and voter names as formulas,
+// a cell starting with a formula character gets a leading apostrophe.
+func neutralize(row []string) []string {
+	out := make([]string, len(row))
+	for i, cell := range row {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cell = "'" + cell
+		}
+		out[i] = cell
+	}
+	return out
+}
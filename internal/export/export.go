@@ -0,0 +1,229 @@
+package export
+
+import (
+	"github.com/pkg/errors"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Format string
+
+const (
+	FormatCsv      = Format("CSV")
+	FormatMarkdown = Format("MARKDOWN")
+	FormatXlsx     = Format("XLSX")
+)
+
+// Formats lists the export formats in the order they are offered.
+var Formats = []Format{FormatXlsx, FormatCsv, FormatMarkdown}
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatCsv:
+		return "csv"
+	case FormatMarkdown:
+		return "md"
+	default:
+		return "xlsx"
+	}
+}
+
+func (f Format) Title() string {
+	switch f {
+	case FormatCsv:
+		return "CSV"
+	case FormatMarkdown:
+		return "Markdown"
+	default:
+		return "Excel (XLSX)"
+	}
+}
+
+// ParseFormat accepts a format name or a file extension, case-insensitively.
+func ParseFormat(text string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(text, string(f)) || strings.EqualFold(text, f.Extension()) {
+			return f, nil
+		}
+	}
+	return "", errors.Wrapf(ErrUnknownFormat, "%q", text)
+}
+
+// Table is the room results: a row per task with the final grade, stats and votes of the last round.
+type Table struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+// fixedColumns go before the vote columns, see BuildTable.
+var fixedColumns = []string{"Задача", "Ссылка", "Оценка", "Медиана", "Мода", "Раунды"}
+
+type Exporter struct {
+	roomService *service.RoomService
+	taskService *service.TaskService
+	rateService *service.RateService
+}
+
+func NewExporter(roomService *service.RoomService, taskService *service.TaskService, rateService *service.RateService) *Exporter {
+	return &Exporter{roomService: roomService, taskService: taskService, rateService: rateService}
+}
+
+// Export builds the results of the room and writes them in the format. The file name is made of the room name.
+func (e *Exporter) Export(roomId string, format Format) (fileName string, data []byte, err error) {
+	room, table, err := e.BuildTable(roomId)
+	if err != nil {
+		return "", nil, err
+	}
+	if data, err = Write(table, format); err != nil {
+		return "", nil, err
+	}
+	return fileBaseName(room.Name) + "." + format.Extension(), data, nil
+}
+
+// BuildTable collects the results of every task of the room. Votes get a column per voter,
+// anonymous rooms get a single column with the votes sorted by points instead.
+func (e *Exporter) BuildTable(roomId string) (model.Room, Table, error) {
+	room, err := e.roomService.GetRoomById(roomId)
+	if err != nil {
+		return model.Room{}, Table{}, err
+	}
+	tasks, err := e.taskService.GetAllTasksByRoomId(roomId)
+	if err != nil {
+		return model.Room{}, Table{}, errors.Wrapf(err, "unable to get tasks for export, roomId: %v", roomId)
+	}
+	members, err := e.roomService.GetMembersByRoomId(roomId)
+	if err != nil {
+		return model.Room{}, Table{}, errors.Wrapf(err, "unable to get members for export, roomId: %v", roomId)
+	}
+
+	type taskResult struct {
+		task  model.Task
+		round model.RoundStats
+	}
+	results := make([]taskResult, 0, len(tasks))
+	voted := map[int64]bool{}
+	for _, task := range tasks {
+		rounds, err := e.rateService.GetRoundStats(task.Id.String())
+		if err != nil {
+			return model.Room{}, Table{}, err
+		}
+		result := taskResult{task: task}
+		if len(rounds) > 0 {
+			result.round = rounds[len(rounds)-1]
+		}
+		for _, rate := range result.round.Rates {
+			voted[rate.UserId] = true
+		}
+		results = append(results, result)
+	}
+
+	table := Table{Title: room.Name, Header: append([]string{}, fixedColumns...)}
+	var voters []int64
+	if room.Anonymous {
+		table.Header = append(table.Header, "Голоса")
+	} else {
+		voters = votersOf(members, voted)
+		names := map[int64]string{}
+		for _, m := range members {
+			names[m.UserId] = m.DisplayName
+		}
+		for _, userId := range voters {
+			name := names[userId]
+			if name == "" {
+				name = "id" + strconv.FormatInt(userId, 10)
+			}
+			table.Header = append(table.Header, name)
+		}
+	}
+
+	for _, result := range results {
+		task, round := result.task, result.round
+		row := []string{task.Name, task.Url, "", "", "", strconv.Itoa(task.Round)}
+		if task.Grade > 0 {
+			row[2] = room.Scale.Label(task.Grade)
+		}
+		if round.Votes > 0 {
+			row[3] = room.Scale.Label(round.Median)
+			row[4] = room.Scale.Label(round.Mode)
+		}
+
+		if room.Anonymous {
+			rates := append([]model.Rate{}, round.Rates...)
+			sort.SliceStable(rates, func(i, j int) bool { return rates[i].Sum < rates[j].Sum })
+			labels := make([]string, 0, len(rates))
+			for _, rate := range rates {
+				labels = append(labels, room.Scale.RateLabel(rate))
+			}
+			row = append(row, strings.Join(labels, ", "))
+		} else {
+			votes := map[int64]string{}
+			for _, rate := range round.Rates {
+				votes[rate.UserId] = room.Scale.RateLabel(rate)
+			}
+			for _, userId := range voters {
+				row = append(row, votes[userId])
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return room, table, nil
+}
+
+// votersOf orders voters by the room members list, voters who left the room go last.
+func votersOf(members []model.Member, voted map[int64]bool) []int64 {
+	var voters []int64
+	seen := map[int64]bool{}
+	for _, m := range members {
+		if voted[m.UserId] || !m.IsObserver() {
+			voters = append(voters, m.UserId)
+			seen[m.UserId] = true
+		}
+	}
+	var left []int64
+	for userId := range voted {
+		if !seen[userId] {
+			left = append(left, userId)
+		}
+	}
+	sort.Slice(left, func(i, j int) bool { return left[i] < left[j] })
+	return append(voters, left...)
+}
+
+// Write renders the table in the format.
+func Write(table Table, format Format) ([]byte, error) {
+	switch format {
+	case FormatCsv:
+		return writeCsv(table)
+	case FormatMarkdown:
+		return writeMarkdown(table), nil
+	case FormatXlsx:
+		return writeXlsx(table)
+	default:
+		return nil, errors.Wrapf(ErrUnknownFormat, "%q", format)
+	}
+}
+
+// fileBaseName keeps letters and digits of the room name, so it is safe on every file system.
+func fileBaseName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteRune('_')
+		}
+	}
+	base := strings.Trim(b.String(), "_")
+	if base == "" {
+		return "room"
+	}
+	return base
+}
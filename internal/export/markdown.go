@@ -0,0 +1,39 @@
+package export
+
+import (
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+
+// writeMarkdown renders the table as a GitHub flavored Markdown table under a header with the room name.
+func writeMarkdown(table Table) []byte {
+	var b strings.Builder
+	b.WriteString("# " + markdownEscaper.Replace(table.Title) + "\n\n")
+
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	header := make([]string, len(table.Header))
+	separator := make([]string, len(table.Header))
+	for i, title := range table.Header {
+		header[i] = markdownEscaper.Replace(title)
+		separator[i] = "---"
+	}
+	writeRow(header)
+	writeRow(separator)
+
+	for _, row := range table.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownEscaper.Replace(cell)
+		}
+		writeRow(cells)
+	}
+	return []byte(b.String())
+}
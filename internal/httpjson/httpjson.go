@@ -0,0 +1,59 @@
+// Package httpjson holds the JSON response helpers shared by the HTTP API and the Web App backend.
+package httpjson
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/go-pkgz/lgr"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service"
+	"net/http"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// InvalidRequestError is answered with 400 and its message.
+type InvalidRequestError struct {
+	message string
+}
+
+func (e *InvalidRequestError) Error() string {
+	return e.message
+}
+
+func InvalidRequest(message string) error {
+	return &InvalidRequestError{message: message}
+}
+
+func Write(rw http.ResponseWriter, status int, body interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(body); err != nil {
+		lgr.Printf("[ERROR] unable to write json response, %v", err)
+	}
+}
+
+func WriteError(rw http.ResponseWriter, status int, message string) {
+	Write(rw, status, errorResponse{Error: message})
+}
+
+// HandleError answers with the status matching the error: denied access, invalid request, missing entity
+// or an internal error. Denied access is audited and internal errors are logged, source names the caller in the log.
+func HandleError(rw http.ResponseWriter, source string, userId int64, req *http.Request, err error) {
+	var denied *service.AccessDeniedError
+	var invalid *InvalidRequestError
+	switch {
+	case errors.As(err, &denied):
+		lgr.Printf("[WARN] audit: %v %v %v: %v", source, req.Method, req.URL.Path, denied)
+		WriteError(rw, http.StatusForbidden, "access denied")
+	case errors.As(err, &invalid):
+		WriteError(rw, http.StatusBadRequest, invalid.message)
+	case errors.Cause(err) == sql.ErrNoRows:
+		WriteError(rw, http.StatusNotFound, "not found")
+	default:
+		lgr.Printf("[ERROR] %v %v %v failed for user %d, %v", source, req.Method, req.URL.Path, userId, err)
+		WriteError(rw, http.StatusInternalServerError, "internal error")
+	}
+}
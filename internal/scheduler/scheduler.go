@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"github.com/go-pkgz/lgr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 3
+	claimBatch         = 20
+	retryBackoff       = 10 * time.Second
+	// jobLease is how long a claimed job may run before another tick treats it as interrupted and claims it again
+	jobLease = 5 * time.Minute
+)
+
+type JobRepository interface {
+	SaveJob(job model.Job) error
+	ClaimJobs(now, lockedUntil time.Time, limit int) ([]model.Job, error)
+	FailExpiredJobs(now time.Time) (int64, error)
+	FinishJob(jobId string, status model.JobStatus, lastError string, date time.Time) error
+	RetryJob(jobId string, runAt time.Time, lastError string) error
+	CancelRoomJobs(roomId string, kinds []string, date time.Time) (int64, error)
+}
+
+type Handler func(payload model.JobPayload) error
+
+// Scheduler runs delayed jobs stored in postgres. A claimed job is leased for jobLease, a job interrupted by a crash
+// is claimed again once the lease expires, so handlers must tolerate running twice. Failed jobs are retried with a backoff.
+type Scheduler struct {
+	rep JobRepository
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewScheduler(rep JobRepository) *Scheduler {
+	return &Scheduler{rep: rep, handlers: map[string]Handler{}}
+}
+
+func (s *Scheduler) Register(kind string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = handler
+}
+
+func (s *Scheduler) Schedule(kind string, runAt time.Time, payload model.JobPayload) error {
+	return s.ScheduleWithAttempts(kind, runAt, payload, defaultMaxAttempts)
+}
+
+// ScheduleWithAttempts schedules a job that is retried until maxAttempts runs failed.
+func (s *Scheduler) ScheduleWithAttempts(kind string, runAt time.Time, payload model.JobPayload, maxAttempts int) error {
+	return s.rep.SaveJob(model.Job{
+		Id:          uuid.New(),
+		Kind:        kind,
+		Payload:     payload,
+		Status:      model.JobNew,
+		RunAt:       runAt,
+		MaxAttempts: maxAttempts,
+		CreatedDate: time.Now(),
+	})
+}
+
+// CancelRoomJobs cancels jobs of the kinds that have not started yet for the room. Jobs find their room by
+// the roomId key of the payload.
+func (s *Scheduler) CancelRoomJobs(roomId string, kinds ...string) (int64, error) {
+	return s.rep.CancelRoomJobs(roomId, kinds, time.Now())
+}
+
+// Tick runs every due job once and returns how many were claimed. It is the entry point for webhook mode.
+func (s *Scheduler) Tick() (int, error) {
+	now := time.Now()
+	if failed, err := s.rep.FailExpiredJobs(now); err != nil {
+		lgr.Printf("[ERROR] unable to fail expired jobs, %v", err)
+	} else if failed > 0 {
+		lgr.Printf("[WARN] %d jobs failed after their lease expired", failed)
+	}
+	jobs, err := s.rep.ClaimJobs(now, now.Add(jobLease), claimBatch)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to claim jobs")
+	}
+	for _, job := range jobs {
+		s.run(job)
+	}
+	return len(jobs), nil
+}
+
+// Start ticks in the background until stop is closed. Used in long polling mode.
+func (s *Scheduler) Start(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := s.Tick(); err != nil {
+					lgr.Printf("[ERROR] scheduler tick failed, %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) run(job model.Job) {
+	s.mu.RLock()
+	handler, ok := s.handlers[job.Kind]
+	s.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = errors.Errorf("no handler for job kind %v", job.Kind)
+	} else {
+		err = safeCall(handler, job.Payload)
+	}
+
+	if err == nil {
+		if err = s.rep.FinishJob(job.Id.String(), model.JobDone, "", time.Now()); err != nil {
+			lgr.Printf("[ERROR] unable to finish job %v, %v", job.Id, err)
+		}
+		return
+	}
+
+	lgr.Printf("[WARN] job %v of kind %v failed, attempt %d/%d: %v", job.Id, job.Kind, job.Attempts, job.MaxAttempts, err)
+	if ok && job.Attempts < job.MaxAttempts {
+		runAt := time.Now().Add(retryBackoff * time.Duration(1<<uint(job.Attempts-1)))
+		if err = s.rep.RetryJob(job.Id.String(), runAt, err.Error()); err != nil {
+			lgr.Printf("[ERROR] unable to retry job %v, %v", job.Id, err)
+		}
+		return
+	}
+	if err = s.rep.FinishJob(job.Id.String(), model.JobFailed, err.Error(), time.Now()); err != nil {
+		lgr.Printf("[ERROR] unable to fail job %v, %v", job.Id, err)
+	}
+}
+
+func safeCall(handler Handler, payload model.JobPayload) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(payload)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"gotestbot/internal/dao"
+	"gotestbot/internal/service/model"
+)
+
+type Permission string
+
+const (
+	PermVote       = Permission("VOTE")
+	PermReveal     = Permission("REVEAL")
+	PermRevote     = Permission("REVOTE")
+	PermGrade      = Permission("GRADE")
+	PermAddTask    = Permission("ADD_TASK")
+	PermNextTask   = Permission("NEXT_TASK")
+	PermFinishRoom = Permission("FINISH_ROOM")
+	PermManageRoom = Permission("MANAGE_ROOM")
+	PermViewRoom   = Permission("VIEW_ROOM")
+	PermAdminRoom  = Permission("ADMIN_ROOM")
+)
+
+type DenyReason string
+
+const (
+	DenyNotMember      = DenyReason("NOT_MEMBER")
+	DenyObserver       = DenyReason("OBSERVER")
+	DenyNotFacilitator = DenyReason("NOT_FACILITATOR")
+	DenyNotOwner       = DenyReason("NOT_OWNER")
+)
+
+type AccessDeniedError struct {
+	Permission Permission
+	Reason     DenyReason
+	UserId     int64
+	RoomId     string
+}
+
+func (e *AccessDeniedError) Error() string {
+	return fmt.Sprintf("access denied: user %d has no %v permission in room %v (%v)", e.UserId, e.Permission, e.RoomId, e.Reason)
+}
+
+// AccessPolicy decides who may do what in a room. Viewing is open to every member, voting to voters and facilitators,
+// everything that drives the planning is reserved for the owner and facilitators. Administration of the room
+// itself (name, chat, members and ownership) belongs to the owner alone.
+type AccessPolicy struct {
+	r *dao.Repository
+}
+
+func NewAccessPolicy(repository *dao.Repository) *AccessPolicy {
+	return &AccessPolicy{r: repository}
+}
+
+// Check returns *AccessDeniedError when the user lacks the permission, other errors mean the check itself failed.
+func (p AccessPolicy) Check(perm Permission, userId int64, roomId string) error {
+	room, err := p.r.GetRoomById(roomId)
+	if err != nil {
+		return errors.Wrapf(err, "cannot check %v permission", perm)
+	}
+	member, err := p.r.GetMember(userId, roomId)
+	if err != nil {
+		return errors.Wrapf(err, "cannot check %v permission", perm)
+	}
+
+	deny := func(reason DenyReason) error {
+		return &AccessDeniedError{Permission: perm, Reason: reason, UserId: userId, RoomId: roomId}
+	}
+
+	isFacilitator := room.UserId == userId || member != nil && member.Role == model.RoleFacilitator
+	switch perm {
+	case PermViewRoom:
+		if member == nil && !isFacilitator {
+			return deny(DenyNotMember)
+		}
+		return nil
+	case PermVote:
+		if member == nil {
+			return deny(DenyNotMember)
+		}
+		if member.IsObserver() {
+			return deny(DenyObserver)
+		}
+		return nil
+	case PermAdminRoom:
+		if room.UserId != userId {
+			return deny(DenyNotOwner)
+		}
+		return nil
+	default:
+		if !isFacilitator {
+			return deny(DenyNotFacilitator)
+		}
+		return nil
+	}
+}
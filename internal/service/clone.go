@@ -0,0 +1,113 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"gotestbot/internal/service/model"
+	"time"
+)
+
+// CloneRoom creates a room of the user with the chat, members, settings and tracker of the source room.
+// The tracker token is kept only for the owner of the source, others have to enter their own.
+// Unfinished tasks are copied when withTasks is set.
+func (s RoomService) CloneRoom(sourceRoomId, name string, userId int64, withTasks bool) (model.Room, error) {
+	source, err := s.Repository.GetRoomById(sourceRoomId)
+	if err != nil {
+		return model.Room{}, err
+	}
+	room := model.Room{
+		Id:           uuid.New(),
+		Status:       model.New,
+		Name:         name,
+		UserId:       userId,
+		ChatId:       source.ChatId,
+		Scale:        source.Scale,
+		Timer:        source.Timer,
+		CreatedDate:  time.Now(),
+		RevealPolicy: source.RevealPolicy,
+		RevealQuorum: source.RevealQuorum,
+		Anonymous:    source.Anonymous,
+		Mode:         source.Mode,
+		AutoJoin:     source.AutoJoin,
+		PresentHours: source.PresentHours,
+	}
+	if err = s.Repository.SaveClonedRoom(room, sourceRoomId, withTasks, source.UserId == userId); err != nil {
+		return model.Room{}, err
+	}
+	members, err := s.Repository.GetMembersByRoomId(sourceRoomId)
+	if err != nil {
+		return room, err
+	}
+	return room, s.addMembers(room, members)
+}
+
+// SaveRoomAsTemplate remembers the chat, members and settings of the room under the name.
+func (s RoomService) SaveRoomAsTemplate(roomId, name string, userId int64) (model.RoomTemplate, error) {
+	room, err := s.Repository.GetRoomById(roomId)
+	if err != nil {
+		return model.RoomTemplate{}, err
+	}
+	template := model.RoomTemplate{
+		Id:           uuid.New(),
+		UserId:       userId,
+		Name:         name,
+		ChatId:       room.ChatId,
+		Scale:        room.Scale,
+		Timer:        room.Timer,
+		CreatedDate:  time.Now(),
+		RevealPolicy: room.RevealPolicy,
+		RevealQuorum: room.RevealQuorum,
+		Anonymous:    room.Anonymous,
+		Mode:         room.Mode,
+		AutoJoin:     room.AutoJoin,
+		PresentHours: room.PresentHours,
+	}
+	if err = s.Repository.SaveTemplate(template, roomId); err != nil {
+		return model.RoomTemplate{}, err
+	}
+	return template, nil
+}
+
+// StartRoomFromTemplate creates a room of the user from the template.
+func (s RoomService) StartRoomFromTemplate(templateId, name string, userId int64) (model.Room, error) {
+	template, err := s.Repository.GetTemplateById(templateId)
+	if err != nil {
+		return model.Room{}, err
+	}
+	room := model.Room{
+		Id:           uuid.New(),
+		Status:       model.New,
+		Name:         name,
+		UserId:       userId,
+		ChatId:       template.ChatId,
+		Scale:        template.Scale,
+		Timer:        template.Timer,
+		CreatedDate:  time.Now(),
+		RevealPolicy: template.RevealPolicy,
+		RevealQuorum: template.RevealQuorum,
+		Anonymous:    template.Anonymous,
+		Mode:         template.Mode,
+		AutoJoin:     template.AutoJoin,
+		PresentHours: template.PresentHours,
+	}
+	if err = s.Repository.SaveRoomFromTemplate(room, templateId); err != nil {
+		return model.Room{}, err
+	}
+	members, err := s.Repository.GetTemplateMembers(templateId)
+	if err != nil {
+		return room, err
+	}
+	return room, s.addMembers(room, members)
+}
+
+// addMembers adds the members to the new room with their roles, the owner is already its facilitator.
+func (s RoomService) addMembers(room model.Room, members []model.Member) error {
+	for _, member := range members {
+		if member.UserId == room.UserId {
+			continue
+		}
+		if err := s.SaveRoomMember(member.UserId, room.Id.String(), member.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
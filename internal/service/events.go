@@ -0,0 +1,53 @@
+package service
+
+import (
+	"github.com/go-pkgz/lgr"
+	"gotestbot/internal/service/model"
+	"sync"
+	"time"
+)
+
+type EventListener func(event model.Event)
+
+// EventBus delivers events emitted by services to listeners synchronously, in the order of subscription.
+// A panicking listener is logged and does not affect the others.
+type EventBus struct {
+	mu        sync.RWMutex
+	listeners []EventListener
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+func (b *EventBus) Subscribe(listener EventListener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, listener)
+}
+
+func (b *EventBus) Publish(event model.Event) {
+	if b == nil {
+		return
+	}
+	if event.Date.IsZero() {
+		event.Date = time.Now()
+	}
+
+	b.mu.RLock()
+	listeners := b.listeners
+	b.mu.RUnlock()
+
+	for _, listener := range listeners {
+		notify(listener, event)
+	}
+}
+
+func notify(listener EventListener, event model.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			lgr.Printf("[ERROR] event listener panicked on %v, %v", event.Type, r)
+		}
+	}()
+	listener(event)
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gotestbot/internal/service/model"
+	"strings"
+)
+
+// GitHubIssues reads a JSON array of issues, as returned by the REST API or by "gh issue list --json number,title,url,state".
+// Closed issues and pull requests are skipped.
+type GitHubIssues struct{}
+
+type githubIssue struct {
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	HtmlUrl     string          `json:"html_url"`
+	Url         string          `json:"url"`
+	State       string          `json:"state"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+func (GitHubIssues) Format() string {
+	return "GitHub issues JSON"
+}
+
+// Detect accepts an array whose first element carries the issue number together with its link or state,
+// other JSON arrays are left to the next importers.
+func (GitHubIssues) Detect(fileName string, data []byte) bool {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+	if !bytes.HasPrefix(data, []byte("[")) {
+		return false
+	}
+	var issues []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &issues); err != nil || len(issues) == 0 {
+		return false
+	}
+	issue := issues[0]
+	if _, ok := issue["number"]; !ok {
+		return false
+	}
+	for _, key := range []string{"html_url", "url", "state"} {
+		if _, ok := issue[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (GitHubIssues) Parse(data []byte) ([]model.TaskDraft, error) {
+	var issues []githubIssue
+	if err := json.Unmarshal(bytes.TrimPrefix(data, utf8BOM), &issues); err != nil {
+		return nil, err
+	}
+
+	var drafts []model.TaskDraft
+	for _, issue := range issues {
+		if issue.Title == "" || strings.EqualFold(issue.State, "closed") || len(issue.PullRequest) > 0 {
+			continue
+		}
+		name := issue.Title
+		if issue.Number > 0 {
+			name = fmt.Sprintf("#%d %s", issue.Number, issue.Title)
+		}
+		link := issue.HtmlUrl
+		if link == "" && strings.HasPrefix(issue.Url, "https://github.com/") {
+			link = issue.Url
+		}
+		drafts = append(drafts, model.TaskDraft{Name: name, Url: link})
+	}
+	return drafts, nil
+}
@@ -0,0 +1,40 @@
+package importer
+
+import (
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+)
+
+// ErrUnknownFormat is returned when no importer recognises the file.
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// Importer turns an export of an issue tracker into task drafts.
+type Importer interface {
+	// Format is the human-readable name of the export format.
+	Format() string
+	// Detect tells whether the file looks like this format, by its name and content.
+	Detect(fileName string, data []byte) bool
+	Parse(data []byte) ([]model.TaskDraft, error)
+}
+
+var importers = []Importer{JiraCSV{}, GitHubIssues{}, TrelloBoard{}}
+
+// Register adds an importer, it is tried after the built-in ones.
+func Register(i Importer) {
+	importers = append(importers, i)
+}
+
+// Import parses the file with the first importer that recognises it and returns the format name with the drafts.
+func Import(fileName string, data []byte) (string, []model.TaskDraft, error) {
+	for _, i := range importers {
+		if !i.Detect(fileName, data) {
+			continue
+		}
+		drafts, err := i.Parse(data)
+		if err != nil {
+			return i.Format(), nil, errors.Wrapf(err, "unable to parse %v", i.Format())
+		}
+		return i.Format(), drafts, nil
+	}
+	return "", nil, ErrUnknownFormat
+}
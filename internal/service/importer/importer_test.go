@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"gotestbot/internal/service/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("unable to read fixture %v: %v", name, err)
+	}
+	return data
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		drafts []model.TaskDraft
+	}{
+		{
+			file:   "jira.csv",
+			format: "Jira CSV",
+			drafts: []model.TaskDraft{
+				{Name: "PRJ-1 Login page"},
+				{Name: "PRJ-2 Export, with comma"},
+			},
+		},
+		{
+			file:   "github.json",
+			format: "GitHub issues JSON",
+			drafts: []model.TaskDraft{
+				{Name: "#12 Fix crash on start", Url: "https://github.com/acme/app/issues/12"},
+			},
+		},
+		{
+			file:   "gh_cli.json",
+			format: "GitHub issues JSON",
+			drafts: []model.TaskDraft{
+				{Name: "#7 Slow search", Url: "https://github.com/acme/app/issues/7"},
+			},
+		},
+		{
+			file:   "trello.json",
+			format: "Trello JSON",
+			drafts: []model.TaskDraft{
+				{Name: "Design onboarding", Url: "https://trello.com/c/abc"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			format, drafts, err := Import(tt.file, readFixture(t, tt.file))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if format != tt.format {
+				t.Errorf("format = %q, want %q", format, tt.format)
+			}
+			if !reflect.DeepEqual(drafts, tt.drafts) {
+				t.Errorf("drafts = %+v, want %+v", drafts, tt.drafts)
+			}
+		})
+	}
+}
+
+func TestImportUnknownArray(t *testing.T) {
+	if _, _, err := Import("other.json", readFixture(t, "other.json")); err != ErrUnknownFormat {
+		t.Fatalf("err = %v, want %v", err, ErrUnknownFormat)
+	}
+}
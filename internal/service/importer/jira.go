@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// JiraCSV reads "Export Excel CSV" of a Jira filter. The issue key is kept in the task name.
+type JiraCSV struct{}
+
+func (JiraCSV) Format() string {
+	return "Jira CSV"
+}
+
+func (JiraCSV) Detect(fileName string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(fileName), ".csv")
+}
+
+func (JiraCSV) Parse(data []byte) ([]model.TaskDraft, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read header")
+	}
+	summary, key, link := -1, -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "summary":
+			summary = i
+		case "issue key", "key":
+			key = i
+		case "url", "link", "issue url":
+			link = i
+		}
+	}
+	if summary < 0 {
+		return nil, errors.New("no Summary column")
+	}
+
+	var drafts []model.TaskDraft
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "unable to read row")
+		}
+		name := strings.TrimSpace(field(record, summary))
+		if name == "" {
+			continue
+		}
+		if k := strings.TrimSpace(field(record, key)); k != "" {
+			name = k + " " + name
+		}
+		drafts = append(drafts, model.TaskDraft{Name: name, Url: strings.TrimSpace(field(record, link))})
+	}
+	return drafts, nil
+}
+
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return record[i]
+}
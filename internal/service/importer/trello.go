@@ -0,0 +1,53 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"gotestbot/internal/service/model"
+)
+
+// TrelloBoard reads the JSON export of a Trello board. Archived cards and cards of archived lists are skipped.
+type TrelloBoard struct{}
+
+type trelloBoard struct {
+	Cards []struct {
+		Name     string `json:"name"`
+		ShortUrl string `json:"shortUrl"`
+		Closed   bool   `json:"closed"`
+		IdList   string `json:"idList"`
+	} `json:"cards"`
+	Lists []struct {
+		Id     string `json:"id"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+}
+
+func (TrelloBoard) Format() string {
+	return "Trello JSON"
+}
+
+func (TrelloBoard) Detect(fileName string, data []byte) bool {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+	return bytes.HasPrefix(data, []byte("{")) && bytes.Contains(data, []byte(`"cards"`))
+}
+
+func (TrelloBoard) Parse(data []byte) ([]model.TaskDraft, error) {
+	var board trelloBoard
+	if err := json.Unmarshal(bytes.TrimPrefix(data, utf8BOM), &board); err != nil {
+		return nil, err
+	}
+
+	closedLists := map[string]bool{}
+	for _, list := range board.Lists {
+		closedLists[list.Id] = list.Closed
+	}
+
+	var drafts []model.TaskDraft
+	for _, card := range board.Cards {
+		if card.Name == "" || card.Closed || closedLists[card.IdList] {
+			continue
+		}
+		drafts = append(drafts, model.TaskDraft{Name: card.Name, Url: card.ShortUrl})
+	}
+	return drafts, nil
+}
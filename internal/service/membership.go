@@ -0,0 +1,67 @@
+package service
+
+import (
+	"gotestbot/internal/service/model"
+)
+
+// LeaveChatRooms removes the user who left the chat from every active room bound to it, with the votes on unfinished tasks.
+// Owners stay in their rooms. Rooms the user was removed from are returned.
+func (s RoomService) LeaveChatRooms(chatId, userId int64) ([]model.Room, error) {
+	rooms, err := s.Repository.GetActiveRoomsByChatId(chatId)
+	if err != nil {
+		return nil, err
+	}
+	var left []model.Room
+	for _, room := range rooms {
+		if room.UserId == userId {
+			continue
+		}
+		member, err := s.Repository.GetMember(userId, room.Id.String())
+		if err != nil {
+			return left, err
+		}
+		if member == nil {
+			continue
+		}
+		if err = s.Repository.DeleteRoomMember(userId, room.Id.String()); err != nil {
+			return left, err
+		}
+		left = append(left, room)
+	}
+	return left, nil
+}
+
+// JoinChatRooms adds the user who joined the chat as a voter to every active room bound to it with auto-join enabled.
+// Rooms the user joined are returned.
+func (s RoomService) JoinChatRooms(chatId, userId int64) ([]model.Room, error) {
+	rooms, err := s.Repository.GetActiveRoomsByChatId(chatId)
+	if err != nil {
+		return nil, err
+	}
+	var joined []model.Room
+	for _, room := range rooms {
+		ok, err := s.AutoJoinRoom(room, userId)
+		if err != nil {
+			return joined, err
+		}
+		if ok {
+			joined = append(joined, room)
+		}
+	}
+	return joined, nil
+}
+
+// AutoJoinRoom adds the user to the room as a voter when the room has auto-join enabled and the user is not a member yet.
+func (s RoomService) AutoJoinRoom(room model.Room, userId int64) (bool, error) {
+	if !room.AutoJoin || room.Status == model.Finished {
+		return false, nil
+	}
+	member, err := s.Repository.GetMember(userId, room.Id.String())
+	if err != nil || member != nil {
+		return false, err
+	}
+	if err = s.SaveRoomMember(userId, room.Id.String(), model.RoleVoter); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -1,7 +1,10 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"time"
 )
 
@@ -12,13 +15,107 @@ const (
 	Finished = RoomStatus("FINISHED")
 )
 
+type RevealPolicy string
+
+const (
+	RevealAll     = RevealPolicy("ALL")
+	RevealPercent = RevealPolicy("PERCENT")
+	RevealCount   = RevealPolicy("COUNT")
+	RevealPresent = RevealPolicy("PRESENT")
+)
+
+type RoomMode string
+
+const (
+	ModeLive  = RoomMode("LIVE")
+	ModeAsync = RoomMode("ASYNC")
+)
+
+type MemberRole string
+
+const (
+	RoleFacilitator = MemberRole("FACILITATOR")
+	RoleVoter       = MemberRole("VOTER")
+	RoleObserver    = MemberRole("OBSERVER")
+)
+
+type RateKind string
+
+const (
+	RateKindEstimate = RateKind("ESTIMATE")
+	RateKindCoffee   = RateKind("COFFEE")
+	RateKindUnknown  = RateKind("UNKNOWN")
+	RateKindInfinity = RateKind("INFINITY")
+)
+
 type Room struct {
 	Id          uuid.UUID  `db:"id"`
 	Status      RoomStatus `db:"status"`
 	Name        string     `db:"name"`
 	UserId      int64      `db:"user_id"`
 	ChatId      int64      `db:"chat_id"`
+	Scale       Scale      `db:"scale"`
+	Timer       int        `db:"timer_seconds"`
 	CreatedDate time.Time  `db:"created_date"`
+
+	RevealPolicy RevealPolicy `db:"reveal_policy"`
+	RevealQuorum int          `db:"reveal_quorum"`
+	Anonymous    bool         `db:"anonymous"`
+	Mode         RoomMode     `db:"mode"`
+	AutoJoin     bool         `db:"auto_join"`
+	PresentHours int          `db:"present_hours"`
+}
+
+// IsAsync tells whether tasks are estimated in batches with private ballots instead of in the group chat.
+func (r Room) IsAsync() bool {
+	return r.Mode == ModeAsync
+}
+
+// PresentWindow is how far back votes on other tasks make a member count as present under the PRESENT reveal policy.
+func (r Room) PresentWindow() time.Duration {
+	return time.Duration(r.PresentHours) * time.Hour
+}
+
+func (r Room) HasTimer() bool {
+	return r.Timer > 0
+}
+
+// TaskDeadline returns when voting on the published task ends. Not yet published tasks get the full timer.
+func (r Room) TaskDeadline(task Task) time.Time {
+	published := time.Now()
+	if task.PublishedDate != nil {
+		published = *task.PublishedDate
+	}
+	return published.Add(time.Duration(r.Timer) * time.Second)
+}
+
+// RoomTemplate keeps the chat, the members and the settings of a room, so new rooms can be started from it.
+type RoomTemplate struct {
+	Id          uuid.UUID `db:"id"`
+	UserId      int64     `db:"user_id"`
+	Name        string    `db:"name"`
+	ChatId      int64     `db:"chat_id"`
+	Scale       Scale     `db:"scale"`
+	Timer       int       `db:"timer_seconds"`
+	CreatedDate time.Time `db:"created_date"`
+
+	RevealPolicy RevealPolicy `db:"reveal_policy"`
+	RevealQuorum int          `db:"reveal_quorum"`
+	Anonymous    bool         `db:"anonymous"`
+	Mode         RoomMode     `db:"mode"`
+	AutoJoin     bool         `db:"auto_join"`
+	PresentHours int          `db:"present_hours"`
+}
+
+type Member struct {
+	UserId      int64      `db:"user_id"`
+	DisplayName string     `db:"display_name"`
+	UserName    string     `db:"user_name"`
+	Role        MemberRole `db:"role"`
+}
+
+func (m Member) IsObserver() bool {
+	return m.Role == RoleObserver
 }
 
 type Task struct {
@@ -28,7 +125,43 @@ type Task struct {
 	RoomId      uuid.UUID `db:"room_id"`
 	Grade       int32     `db:"grade"`
 	Finished    bool      `db:"finished"`
+	Round       int       `db:"round"`
 	CreatedDate time.Time `db:"created_date"`
+
+	ChatId        int64      `db:"chat_id"`
+	MessageId     int        `db:"message_id"`
+	PublishedDate *time.Time `db:"published_date"`
+
+	BatchId uuid.NullUUID `db:"batch_id"`
+}
+
+type BatchStatus string
+
+const (
+	BatchOpen     = BatchStatus("OPEN")
+	BatchRevealed = BatchStatus("REVEALED")
+)
+
+// Batch is a set of tasks of an asynchronous room voted in private ballots until the deadline.
+type Batch struct {
+	Id          uuid.UUID   `db:"id"`
+	RoomId      uuid.UUID   `db:"room_id"`
+	Deadline    time.Time   `db:"deadline"`
+	Status      BatchStatus `db:"status"`
+	CreatedDate time.Time   `db:"created_date"`
+}
+
+// TaskDraft is a task parsed from a bulk message, not saved yet.
+type TaskDraft struct {
+	Name string
+	Url  string
+}
+
+// InvalidLine is a line of a bulk message that cannot become a task. Number starts from 1.
+type InvalidLine struct {
+	Number int
+	Text   string
+	Reason string
 }
 
 type Rate struct {
@@ -36,5 +169,165 @@ type Rate struct {
 	UserId      int64     `db:"user_id"`
 	TaskId      uuid.UUID `db:"task_id"`
 	Sum         int32     `db:"sum"`
+	Kind        RateKind  `db:"kind"`
+	Round       int       `db:"round"`
 	CreatedDate time.Time `db:"created_date"`
 }
+
+func (r Rate) IsEstimate() bool {
+	return r.Kind == RateKindEstimate
+}
+
+// RoundStats describes one voting round of a task.
+type RoundStats struct {
+	Round int
+	Rates []Rate
+	RateStats
+}
+
+type Consensus string
+
+const (
+	ConsensusNone  = Consensus("NONE")
+	ConsensusLow   = Consensus("LOW")
+	ConsensusHigh  = Consensus("HIGH")
+	ConsensusFull  = Consensus("FULL")
+	ConsensusEmpty = Consensus("EMPTY")
+)
+
+type HistogramBar struct {
+	Label string
+	Count int
+}
+
+// RateStats summarises votes of a round. Everything except Histogram and Abstentions is calculated over estimates only,
+// Median is snapped to the nearest card of the room scale.
+type RateStats struct {
+	Votes         int
+	Abstentions   int
+	Mean          float64
+	Median        int32
+	Mode          int32
+	Min           int32
+	Max           int32
+	StdDev        float64
+	Histogram     []HistogramBar
+	LowestVoters  []int64
+	HighestVoters []int64
+	Consensus     Consensus
+}
+
+type EventType string
+
+const (
+	EventTaskPublished = EventType("task.published")
+	EventTaskRated     = EventType("task.rated")
+	EventTaskRevealed  = EventType("task.revealed")
+	EventTaskGraded    = EventType("task.graded")
+	EventRoomFinished  = EventType("room.finished")
+	EventMemberJoined  = EventType("member.joined")
+)
+
+// Event is something that happened in a room. TaskId and UserId are set when the event is about them.
+type Event struct {
+	Type   EventType
+	RoomId string
+	TaskId string
+	UserId int64
+	Date   time.Time
+}
+
+// Webhook receives events of a room. Deployment-wide webhooks come from the config and have no room.
+type Webhook struct {
+	Id          uuid.UUID     `db:"id"`
+	RoomId      uuid.NullUUID `db:"room_id"`
+	Url         string        `db:"url"`
+	Secret      string        `db:"secret"`
+	CreatedDate time.Time     `db:"created_date"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   = DeliveryStatus("PENDING")
+	DeliveryDelivered = DeliveryStatus("DELIVERED")
+	DeliveryFailed    = DeliveryStatus("FAILED")
+)
+
+// WebhookDelivery is a log entry of one event sent to one webhook. WebhookId is empty for deployment-wide webhooks.
+type WebhookDelivery struct {
+	Id            uuid.UUID      `db:"id"`
+	WebhookId     uuid.NullUUID  `db:"webhook_id"`
+	Url           string         `db:"url"`
+	Event         EventType      `db:"event"`
+	Payload       string         `db:"payload"`
+	Status        DeliveryStatus `db:"status"`
+	Attempts      int            `db:"attempts"`
+	ResponseCode  int            `db:"response_code"`
+	LastError     string         `db:"last_error"`
+	CreatedDate   time.Time      `db:"created_date"`
+	DeliveredDate *time.Time     `db:"delivered_date"`
+}
+
+type TrackerKind string
+
+const (
+	TrackerJira   = TrackerKind("JIRA")
+	TrackerGitHub = TrackerKind("GITHUB")
+)
+
+// TrackerConfig binds a room to an issue tracker. PointsField is the story points field id in Jira
+// and the label prefix in GitHub.
+type TrackerConfig struct {
+	RoomId      uuid.UUID   `db:"room_id"`
+	Kind        TrackerKind `db:"kind"`
+	BaseUrl     string      `db:"base_url"`
+	UserName    string      `db:"user_name"`
+	Token       string      `db:"token"`
+	PointsField string      `db:"points_field"`
+	CreatedDate time.Time   `db:"created_date"`
+}
+
+type JobStatus string
+
+const (
+	JobNew      = JobStatus("NEW")
+	JobRunning  = JobStatus("RUNNING")
+	JobDone     = JobStatus("DONE")
+	JobFailed   = JobStatus("FAILED")
+	JobCanceled = JobStatus("CANCELED")
+)
+
+type Job struct {
+	Id           uuid.UUID  `db:"id"`
+	Kind         string     `db:"kind"`
+	Payload      JobPayload `db:"payload"`
+	Status       JobStatus  `db:"status"`
+	RunAt        time.Time  `db:"run_at"`
+	Attempts     int        `db:"attempts"`
+	MaxAttempts  int        `db:"max_attempts"`
+	LastError    string     `db:"last_error"`
+	CreatedDate  time.Time  `db:"created_date"`
+	FinishedDate *time.Time `db:"finished_date"`
+	LockedUntil  *time.Time `db:"locked_until"`
+}
+
+type JobPayload map[string]string
+
+func (p JobPayload) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *JobPayload) Scan(src interface{}) error {
+	switch val := src.(type) {
+	case []byte:
+		return json.Unmarshal(val, p)
+	case string:
+		return json.Unmarshal([]byte(val), p)
+	default:
+		return errors.Errorf("invalid type for JobPayload: %T", src)
+	}
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Scale string
+
+const (
+	ScaleFibonacci     = Scale("FIBONACCI")
+	ScaleFullFibonacci = Scale("FULL_FIBONACCI")
+	ScalePowersOfTwo   = Scale("POWERS_OF_TWO")
+	ScaleTShirt        = Scale("T_SHIRT")
+)
+
+// Scales lists the estimation scales a room can be created with, in the order they are offered.
+var Scales = []Scale{ScaleFibonacci, ScaleFullFibonacci, ScalePowersOfTwo, ScaleTShirt}
+
+// Card is a single vote value of a scale. Points is what gets stored in rate.sum and task.grade.
+type Card struct {
+	Label  string
+	Points int32
+	Kind   RateKind
+}
+
+// Abstentions are offered on every scale. They are stored with zero points and never take part in median or mode.
+var Abstentions = []Card{
+	{"☕️", 0, RateKindCoffee},
+	{"?", 0, RateKindUnknown},
+	{"∞", 0, RateKindInfinity},
+}
+
+var scaleCards = map[Scale][]Card{
+	ScaleFibonacci:     numericCards(0, 1, 2, 3, 5, 8),
+	ScaleFullFibonacci: numericCards(0, 1, 2, 3, 5, 8, 13, 21, 40, 100),
+	ScalePowersOfTwo:   numericCards(0, 1, 2, 4, 8, 16, 32, 64),
+	ScaleTShirt: {
+		{Label: "XS", Points: 1}, {Label: "S", Points: 2}, {Label: "M", Points: 3},
+		{Label: "L", Points: 5}, {Label: "XL", Points: 8},
+	},
+}
+
+func numericCards(points ...int32) []Card {
+	cards := make([]Card, 0, len(points))
+	for _, p := range points {
+		cards = append(cards, Card{Label: strconv.Itoa(int(p)), Points: p})
+	}
+	return cards
+}
+
+var scaleTitles = map[Scale]string{
+	ScaleFibonacci:     "Фибоначчи (0-8)",
+	ScaleFullFibonacci: "Фибоначчи (0-100)",
+	ScalePowersOfTwo:   "Степени двойки",
+	ScaleTShirt:        "Размеры футболок (XS-XL)",
+}
+
+// Cards returns vote values of the scale. Rooms created before scales existed fall back to Fibonacci.
+func (s Scale) Cards() []Card {
+	if cards, ok := scaleCards[s]; ok {
+		return cards
+	}
+	return scaleCards[ScaleFibonacci]
+}
+
+func (s Scale) Title() string {
+	if title, ok := scaleTitles[s]; ok {
+		return title
+	}
+	return scaleTitles[ScaleFibonacci]
+}
+
+func (s Scale) IsNumeric() bool {
+	for _, card := range s.Cards() {
+		if card.Label != strconv.Itoa(int(card.Points)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Card finds the card or abstention by its label, case-insensitively.
+func (s Scale) Card(label string) (Card, bool) {
+	label = strings.TrimSpace(label)
+	for _, card := range s.Cards() {
+		if strings.EqualFold(card.Label, label) {
+			card.Kind = RateKindEstimate
+			return card, true
+		}
+	}
+	for _, card := range Abstentions {
+		if card.Label == label {
+			return card, true
+		}
+	}
+	return Card{}, false
+}
+
+// Label renders points as a card label, or as a plain number if no card has exactly these points.
+func (s Scale) Label(points int32) string {
+	for _, card := range s.Cards() {
+		if card.Points == points {
+			return card.Label
+		}
+	}
+	return strconv.Itoa(int(points))
+}
+
+// RateLabel renders a vote: abstentions as their symbol, estimates as a card label.
+func (s Scale) RateLabel(rate Rate) string {
+	for _, card := range Abstentions {
+		if card.Kind == rate.Kind {
+			return card.Label
+		}
+	}
+	return s.Label(rate.Sum)
+}
+
+// ParseGrade accepts either a card label or an integer number of points.
+func (s Scale) ParseGrade(text string) (int32, bool) {
+	if card, ok := s.Card(text); ok && card.Kind == RateKindEstimate {
+		return card.Points, true
+	}
+	points, err := strconv.ParseInt(strings.TrimSpace(text), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(points), true
+}
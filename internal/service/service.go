@@ -1,33 +1,127 @@
 package service
 
 import (
+	"github.com/go-pkgz/lgr"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"gotestbot/internal/dao"
 	"gotestbot/internal/service/model"
+	"time"
 )
 
+// ErrNoTasks is returned when a batch is published for a room without tasks to estimate.
+var ErrNoTasks = errors.New("no tasks to estimate")
+
+// ErrRoomFinished is returned when a room is finished for the second time.
+var ErrRoomFinished = errors.New("room is already finished")
+
 type RoomService struct {
 	*dao.Repository
+	events *EventBus
+}
+
+func NewRoomService(repository *dao.Repository, events *EventBus) *RoomService {
+	return &RoomService{Repository: repository, events: events}
+}
+
+func (s RoomService) SetStatusRoom(status model.RoomStatus, roomId string) error {
+	if err := s.Repository.SetStatusRoom(status, roomId); err != nil {
+		return err
+	}
+	if status == model.Finished {
+		s.events.Publish(model.Event{Type: model.EventRoomFinished, RoomId: roomId})
+	}
+	return nil
+}
+
+// FinishRoom finishes the planning on behalf of the user. The bot listens to room.finished to post the summary
+// to the room chat and notify the owner, so the bot and the API finish rooms the same way.
+func (s RoomService) FinishRoom(roomId string, userId int64) (model.Room, error) {
+	room, err := s.Repository.GetRoomById(roomId)
+	if err != nil {
+		return model.Room{}, err
+	}
+	if room.Status == model.Finished {
+		return room, ErrRoomFinished
+	}
+	if err = s.Repository.SetStatusRoom(model.Finished, roomId); err != nil {
+		return model.Room{}, err
+	}
+	room.Status = model.Finished
+	s.events.Publish(model.Event{Type: model.EventRoomFinished, RoomId: roomId, UserId: userId})
+	return room, nil
 }
 
-func NewRoomService(repository *dao.Repository) *RoomService {
-	return &RoomService{Repository: repository}
+// SaveRoomMember adds the user to the room or changes the role. Only users new to the room emit member.joined.
+func (s RoomService) SaveRoomMember(userId int64, roomId string, role model.MemberRole) error {
+	previous, err := s.Repository.GetMember(userId, roomId)
+	if err != nil {
+		return err
+	}
+	if err = s.Repository.SaveRoomMember(userId, roomId, role); err != nil {
+		return err
+	}
+	if previous == nil {
+		s.events.Publish(model.Event{Type: model.EventMemberJoined, RoomId: roomId, UserId: userId})
+	}
+	return nil
 }
 
 type TaskService struct {
-	r *dao.Repository
+	r      *dao.Repository
+	events *EventBus
 }
 
-func NewTaskService(repository *dao.Repository) *TaskService {
-	return &TaskService{r: repository}
+func NewTaskService(repository *dao.Repository, events *EventBus) *TaskService {
+	return &TaskService{r: repository, events: events}
+}
+
+// publishTaskEvent emits an event about the task, the room is taken from the task.
+func (s TaskService) publishTaskEvent(eventType model.EventType, task model.Task) {
+	s.events.Publish(model.Event{Type: eventType, RoomId: task.RoomId.String(), TaskId: task.Id.String()})
+}
+
+func (s TaskService) publishTaskEventById(eventType model.EventType, taskId string) {
+	task, err := s.r.GetTaskById(taskId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to emit %v, %v", eventType, err)
+		return
+	}
+	s.publishTaskEvent(eventType, task)
 }
 
 func (s TaskService) SaveTask(task model.Task) error {
 	return s.r.SaveTask(task)
 }
 
-func (s TaskService) SetFinished(taskId string) error {
-	return s.r.SetFinishedTask(taskId)
+// SaveDrafts creates tasks of the room from parsed drafts at once. Creation dates keep the order of the drafts.
+func (s TaskService) SaveDrafts(roomId string, drafts []model.TaskDraft) error {
+	id, err := uuid.Parse(roomId)
+	if err != nil {
+		return errors.Wrapf(err, "invalid roomId %v", roomId)
+	}
+	now := time.Now()
+	tasks := make([]model.Task, 0, len(drafts))
+	for i, draft := range drafts {
+		tasks = append(tasks, model.Task{
+			Id:          uuid.New(),
+			Name:        draft.Name,
+			Url:         draft.Url,
+			RoomId:      id,
+			CreatedDate: now.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+	return s.r.SaveTasks(tasks)
+}
+
+// SetFinished reveals the task. It reports false when the task was already revealed by someone else.
+func (s TaskService) SetFinished(taskId string) (bool, error) {
+	finished, err := s.r.SetFinishedTask(taskId)
+	if err != nil || !finished {
+		return false, err
+	}
+	s.publishTaskEventById(model.EventTaskRevealed, taskId)
+	return true, nil
 }
 
 func (s TaskService) GetTaskById(taskId string) (model.Task, error) {
@@ -38,36 +132,190 @@ func (s TaskService) GetTasksByRoomId(roomId string) ([]model.Task, error) {
 	return s.r.GetTasksByRoomId(roomId, 0, 100)
 }
 
+func (s TaskService) GetAllTasksByRoomId(roomId string) ([]model.Task, error) {
+	return s.r.GetAllTasksByRoomId(roomId)
+}
+
 func (s TaskService) GetTasksByRoomIdAndPagination(roomId string, offset, limit int) ([]model.Task, error) {
 	return s.r.GetTasksByRoomId(roomId, offset, limit)
 }
 
+// GetCurrentTask returns the task published last in the room, nil if nothing was published yet.
+func (s TaskService) GetCurrentTask(roomId string) (*model.Task, error) {
+	return s.r.GetCurrentTask(roomId)
+}
+
 func (s TaskService) GetNextNotFinishedTask(roomId string) (model.Task, error) {
 	return s.r.GetNextNotFinishedTask(roomId)
 }
 
+// TaskFinished tells whether the current round collected enough votes to be revealed under the room policy.
 func (s TaskService) TaskFinished(taskId string) (bool, error) {
-	return s.r.TaskFinished(taskId)
+	task, err := s.r.GetTaskById(taskId)
+	if err != nil {
+		return false, err
+	}
+	room, err := s.r.GetRoomById(task.RoomId.String())
+	if err != nil {
+		return false, err
+	}
+	voted, members, err := s.r.CountTaskVotes(taskId)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot count votes, taskId=%v", taskId)
+	}
+	if voted == 0 {
+		return false, nil
+	}
+
+	switch room.RevealPolicy {
+	case model.RevealPercent:
+		return voted*100 >= members*room.RevealQuorum, nil
+	case model.RevealCount:
+		return voted >= room.RevealQuorum || voted >= members, nil
+	case model.RevealPresent:
+		present, missing, err := s.r.CountPresentMembers(taskId, time.Now().Add(-room.PresentWindow()))
+		if err != nil {
+			return false, errors.Wrapf(err, "cannot count present members, taskId=%v", taskId)
+		}
+		if present > 0 {
+			return missing == 0, nil
+		}
+	}
+	return voted >= members, nil
+}
+
+func (s TaskService) SetPublished(taskId string, chatId int64, messageId int) error {
+	if err := s.r.SetPublishedTask(taskId, chatId, messageId, time.Now()); err != nil {
+		return err
+	}
+	s.publishTaskEventById(model.EventTaskPublished, taskId)
+	return nil
+}
+
+func (s TaskService) StartNewRound(taskId string) error {
+	return s.r.StartNewRoundTask(taskId)
+}
+
+// PublishBatch opens a batch with all not yet estimated tasks of the room. It fails when there is nothing to send.
+func (s TaskService) PublishBatch(roomId string, deadline time.Time) (model.Batch, []model.Task, error) {
+	id, err := uuid.Parse(roomId)
+	if err != nil {
+		return model.Batch{}, nil, errors.Wrapf(err, "invalid roomId %v", roomId)
+	}
+	batch := model.Batch{
+		Id:          uuid.New(),
+		RoomId:      id,
+		Deadline:    deadline,
+		Status:      model.BatchOpen,
+		CreatedDate: time.Now(),
+	}
+	tasks, err := s.r.SaveBatch(batch)
+	if err != nil {
+		return model.Batch{}, nil, err
+	}
+	if len(tasks) == 0 {
+		return model.Batch{}, nil, ErrNoTasks
+	}
+	for _, task := range tasks {
+		s.publishTaskEvent(model.EventTaskPublished, task)
+	}
+	return batch, tasks, nil
+}
+
+func (s TaskService) GetBatchById(batchId string) (model.Batch, error) {
+	return s.r.GetBatchById(batchId)
+}
+
+func (s TaskService) GetOpenBatches(roomId string) ([]model.Batch, error) {
+	return s.r.GetOpenBatchesByRoomId(roomId)
+}
+
+func (s TaskService) GetTasksByBatchId(batchId string) ([]model.Task, error) {
+	return s.r.GetTasksByBatchId(batchId)
+}
+
+// BatchFinished tells whether every voting member filled in the ballot of every task of the batch.
+func (s TaskService) BatchFinished(batchId string) (bool, error) {
+	missing, err := s.r.CountMissingBallots(batchId)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot count missing ballots, batchId=%v", batchId)
+	}
+	return missing == 0, nil
+}
+
+// RevealBatch closes the batch and finishes its tasks. It returns nothing when the batch is already revealed.
+func (s TaskService) RevealBatch(batchId string) ([]model.Task, error) {
+	revealed, err := s.r.RevealBatch(batchId)
+	if err != nil || !revealed {
+		return nil, err
+	}
+	tasks, err := s.r.GetTasksByBatchId(batchId)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		finished, err := s.r.SetFinishedTask(task.Id.String())
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot finish task of batch, taskId=%v", task.Id)
+		}
+		if finished {
+			s.publishTaskEvent(model.EventTaskRevealed, task)
+		}
+	}
+	return tasks, nil
 }
 
 func (s TaskService) SetGradeTask(grade int32, taskId string) error {
-	return s.r.SetGradeTask(grade, taskId)
+	if err := s.r.SetGradeTask(grade, taskId); err != nil {
+		return err
+	}
+	s.publishTaskEventById(model.EventTaskGraded, taskId)
+	return nil
 }
 
 type RateService struct {
-	r *dao.Repository
+	r      *dao.Repository
+	events *EventBus
 }
 
-func NewRateService(repository *dao.Repository) *RateService {
-	return &RateService{r: repository}
+func NewRateService(repository *dao.Repository, events *EventBus) *RateService {
+	return &RateService{r: repository, events: events}
 }
 
 func (s RateService) GetRatesByTaskId(taskId string) ([]model.Rate, error) {
 	return s.r.GetRatesByTaskId(taskId)
 }
 
-func (s RateService) DelRatesByTaskId(taskId string) error {
-	return s.r.DelRatesByTaskId(taskId)
+// GetRoundStats returns every round of the task, the current one last. Rounds nobody voted in are kept empty.
+func (s RateService) GetRoundStats(taskId string) ([]model.RoundStats, error) {
+	task, err := s.r.GetTaskById(taskId)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := s.r.GetAllRoundsRatesByTaskId(taskId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot querying rates of all rounds, taskId=%v", taskId)
+	}
+
+	room, err := s.r.GetRoomById(task.RoomId.String())
+	if err != nil {
+		return nil, err
+	}
+
+	rounds := make([]model.RoundStats, task.Round)
+	for i := range rounds {
+		rounds[i].Round = i + 1
+	}
+	for _, rate := range rates {
+		if rate.Round < 1 || rate.Round > task.Round {
+			continue
+		}
+		rounds[rate.Round-1].Rates = append(rounds[rate.Round-1].Rates, rate)
+	}
+	for i := range rounds {
+		rounds[i].RateStats = calcStats(room.Scale, rounds[i].Rates)
+	}
+	return rounds, nil
 }
 
 func (s RateService) GetRatesSums(taskId string) ([]int32, error) {
@@ -75,11 +323,7 @@ func (s RateService) GetRatesSums(taskId string) ([]int32, error) {
 	if err != nil {
 		return []int32{}, err
 	}
-	var sumRates []int32
-	for _, rate := range rates {
-		sumRates = append(sumRates, rate.Sum)
-	}
-	return sumRates, nil
+	return estimateSums(rates), nil
 
 }
 
@@ -98,6 +342,13 @@ func (s RateService) UpsertRate(rate model.Rate) error {
 			return errors.Wrapf(err, "cannot querying for update rate. rateId=%v", rate.Id.String())
 		}
 	}
+
+	task, err := s.r.GetTaskById(rate.TaskId.String())
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get task for %v event, %v", model.EventTaskRated, err)
+		return nil
+	}
+	s.events.Publish(model.Event{Type: model.EventTaskRated, RoomId: task.RoomId.String(), TaskId: task.Id.String(), UserId: rate.UserId})
 	return nil
 }
 
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"gotestbot/internal/service/model"
+	"math"
+	"sort"
+)
+
+func estimateSums(rates []model.Rate) []int32 {
+	var sums []int32
+	for _, rate := range rates {
+		if rate.IsEstimate() {
+			sums = append(sums, rate.Sum)
+		}
+	}
+	return sums
+}
+
+func calcStats(scale model.Scale, rates []model.Rate) model.RateStats {
+	stats := model.RateStats{Consensus: model.ConsensusEmpty}
+
+	counts := map[string]int{}
+	for _, rate := range rates {
+		counts[scale.RateLabel(rate)]++
+		if !rate.IsEstimate() {
+			stats.Abstentions++
+		}
+	}
+	for _, card := range append(append([]model.Card{}, scale.Cards()...), model.Abstentions...) {
+		if counts[card.Label] > 0 {
+			stats.Histogram = append(stats.Histogram, model.HistogramBar{Label: card.Label, Count: counts[card.Label]})
+		}
+	}
+
+	sums := estimateSums(rates)
+	stats.Votes = len(sums)
+	if stats.Votes == 0 {
+		return stats
+	}
+
+	stats.Min, stats.Max = sums[0], sums[0]
+	var total float64
+	for _, sum := range sums {
+		total += float64(sum)
+		if sum < stats.Min {
+			stats.Min = sum
+		}
+		if sum > stats.Max {
+			stats.Max = sum
+		}
+	}
+	stats.Mean = total / float64(len(sums))
+
+	var variance float64
+	for _, sum := range sums {
+		variance += (float64(sum) - stats.Mean) * (float64(sum) - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(sums)))
+
+	stats.Median = snapToScale(scale, calcMedian(sums))
+	stats.Mode = calcMode(sums)
+
+	for _, rate := range rates {
+		if !rate.IsEstimate() {
+			continue
+		}
+		if rate.Sum == stats.Min {
+			stats.LowestVoters = append(stats.LowestVoters, rate.UserId)
+		}
+		if rate.Sum == stats.Max && stats.Max != stats.Min {
+			stats.HighestVoters = append(stats.HighestVoters, rate.UserId)
+		}
+	}
+	stats.Consensus = calcConsensus(scale, stats.Min, stats.Max)
+	return stats
+}
+
+func calcMedian(sums []int32) float64 {
+	if len(sums) == 0 {
+		return 0
+	}
+	sorted := append([]int32(nil), sums...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mNumber := len(sorted) / 2
+
+	if len(sorted)%2 == 1 {
+		return float64(sorted[mNumber])
+	}
+	return float64(sorted[mNumber-1]+sorted[mNumber]) / 2
+}
+
+// snapToScale picks the nearest card, the bigger one on ties, so 3 and 5 give 5 rather than 4.
+func snapToScale(scale model.Scale, value float64) int32 {
+	cards := scale.Cards()
+	best := cards[0].Points
+	for _, card := range cards[1:] {
+		if math.Abs(float64(card.Points)-value) <= math.Abs(float64(best)-value) {
+			best = card.Points
+		}
+	}
+	return best
+}
+
+// calcMode mirrors postgres mode(): the most frequent value, the smallest one on ties.
+func calcMode(sums []int32) int32 {
+	counts := map[int32]int{}
+	var mode int32
+	for _, sum := range sums {
+		counts[sum]++
+		if counts[sum] > counts[mode] || counts[sum] == counts[mode] && sum < mode {
+			mode = sum
+		}
+	}
+	return mode
+}
+
+// calcConsensus measures how many cards lie between the lowest and the highest estimate.
+func calcConsensus(scale model.Scale, min, max int32) model.Consensus {
+	if min == max {
+		return model.ConsensusFull
+	}
+	distance := cardIndex(scale, max) - cardIndex(scale, min)
+	switch {
+	case distance <= 1:
+		return model.ConsensusHigh
+	case distance == 2:
+		return model.ConsensusLow
+	default:
+		return model.ConsensusNone
+	}
+}
+
+func cardIndex(scale model.Scale, points int32) int {
+	index := 0
+	for i, card := range scale.Cards() {
+		if card.Points <= points {
+			index = i
+		}
+	}
+	return index
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"gotestbot/internal/service/model"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// ParseTaskLines parses one task per line: "name", "name | url" or "url name".
+// Blank lines are skipped, list markers like "-", "*" or "1." are dropped.
+func ParseTaskLines(text string) ([]model.TaskDraft, []model.InvalidLine) {
+	var drafts []model.TaskDraft
+	var invalid []model.InvalidLine
+	seen := map[string]bool{}
+
+	for i, line := range strings.Split(text, "\n") {
+		line = trimListMarker(strings.TrimSpace(line))
+		if line == "" {
+			continue
+		}
+
+		draft, reason := parseTaskLine(line)
+		if reason == "" && seen[strings.ToLower(draft.Name)] {
+			reason = "повторяется"
+		}
+		if reason != "" {
+			invalid = append(invalid, model.InvalidLine{Number: i + 1, Text: line, Reason: reason})
+			continue
+		}
+		seen[strings.ToLower(draft.Name)] = true
+		drafts = append(drafts, draft)
+	}
+	return drafts, invalid
+}
+
+func parseTaskLine(line string) (model.TaskDraft, string) {
+	if sep := strings.LastIndex(line, "|"); sep >= 0 {
+		name := strings.TrimSpace(line[:sep])
+		link := strings.TrimSpace(line[sep+1:])
+		switch {
+		case name == "":
+			return model.TaskDraft{}, "нет названия"
+		case link != "" && !isLink(link):
+			return model.TaskDraft{}, "некорректная ссылка"
+		}
+		return model.TaskDraft{Name: name, Url: link}, ""
+	}
+
+	fields := strings.Fields(line)
+	if isLink(fields[0]) {
+		name := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+		if name == "" {
+			return model.TaskDraft{}, "нет названия"
+		}
+		return model.TaskDraft{Name: name, Url: fields[0]}, ""
+	}
+	return model.TaskDraft{Name: line}, ""
+}
+
+func isLink(text string) bool {
+	u, err := url.Parse(text)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func trimListMarker(line string) string {
+	switch {
+	case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "), strings.HasPrefix(line, "• "):
+		return strings.TrimSpace(line[strings.Index(line, " "):])
+	}
+	digits := strings.IndexFunc(line, func(r rune) bool { return !unicode.IsDigit(r) })
+	if digits > 0 && digits+1 < len(line) && (line[digits] == '.' || line[digits] == ')') && line[digits+1] == ' ' {
+		return strings.TrimSpace(line[digits+1:])
+	}
+	return line
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"gotestbot/internal/dao"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid api token")
+
+// TokenService issues API tokens. Only sha256 of a token is stored, the token itself is shown to the user once.
+type TokenService struct {
+	r *dao.Repository
+}
+
+func NewTokenService(repository *dao.Repository) *TokenService {
+	return &TokenService{r: repository}
+}
+
+// Issue creates a new token for the user, previous tokens stop working.
+func (s TokenService) Issue(userId int64) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	if err := s.r.ReplaceApiToken(userId, hashToken(token), time.Now()); err != nil {
+		return "", errors.Wrapf(err, "cannot save api token, userId=%v", userId)
+	}
+	return token, nil
+}
+
+// Authenticate returns the user the token was issued to.
+func (s TokenService) Authenticate(token string) (int64, error) {
+	if token == "" {
+		return 0, ErrInvalidToken
+	}
+	userId, err := s.r.UseApiToken(hashToken(token), time.Now())
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidToken
+	}
+	return userId, err
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
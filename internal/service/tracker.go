@@ -0,0 +1,161 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"gotestbot/internal/dao"
+	"gotestbot/internal/service/model"
+	"gotestbot/internal/tracker"
+	"gotestbot/internal/webhook"
+	"strings"
+)
+
+// sealedPrefix marks tokens encrypted with the tracker secret, tokens saved before encryption have no prefix.
+const sealedPrefix = "enc:"
+
+var ErrNoTrackerSecret = errors.New("tracker secret is not configured")
+
+// TrackerService connects tasks with issues of the tracker bound to their room.
+// Tracker tokens are stored encrypted with AES-GCM under a key derived from the secret.
+type TrackerService struct {
+	r         *dao.Repository
+	newClient func(conf model.TrackerConfig) (tracker.TrackerClient, error)
+	key       []byte
+}
+
+func NewTrackerService(repository *dao.Repository, secret string) *TrackerService {
+	s := &TrackerService{r: repository, newClient: tracker.NewClient}
+	if secret != "" {
+		key := sha256.Sum256([]byte(secret))
+		s.key = key[:]
+	}
+	return s
+}
+
+func (s TrackerService) GetTracker(roomId string) (*model.TrackerConfig, error) {
+	return s.r.GetTracker(roomId)
+}
+
+// SaveTracker checks the tracker url like webhook urls, since requests to it carry the room token.
+func (s TrackerService) SaveTracker(conf model.TrackerConfig) error {
+	if conf.BaseUrl != "" {
+		if err := webhook.ValidateUrl(conf.BaseUrl); err != nil {
+			return errors.Wrapf(err, "invalid tracker url %q", conf.BaseUrl)
+		}
+	}
+	if _, err := s.newClient(conf); err != nil {
+		return err
+	}
+	token, err := s.sealToken(conf.Token)
+	if err != nil {
+		return err
+	}
+	conf.Token = token
+	return s.r.SaveTracker(conf)
+}
+
+func (s TrackerService) DeleteTracker(roomId string) error {
+	return s.r.DeleteTracker(roomId)
+}
+
+// FindIssue fetches the issue the link points to. It returns nil when the room has no tracker
+// or the link is not an issue of it.
+func (s TrackerService) FindIssue(roomId, link string) (*tracker.Issue, error) {
+	client, key, err := s.clientFor(roomId, link)
+	if err != nil || client == nil {
+		return nil, err
+	}
+	issue, err := client.GetIssue(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get issue %v", key)
+	}
+	return &issue, nil
+}
+
+// WriteBackGrade writes the final grade of the task to its issue. Tasks without a recognised issue are skipped.
+func (s TrackerService) WriteBackGrade(taskId string) error {
+	task, err := s.r.GetTaskById(taskId)
+	if err != nil {
+		return err
+	}
+	client, key, err := s.clientFor(task.RoomId.String(), task.Url)
+	if err != nil || client == nil {
+		return err
+	}
+	if err = client.SetPoints(key, task.Grade); err != nil {
+		return errors.Wrapf(err, "unable to set story points of issue %v", key)
+	}
+	return nil
+}
+
+func (s TrackerService) clientFor(roomId, link string) (tracker.TrackerClient, string, error) {
+	conf, err := s.r.GetTracker(roomId)
+	// a tracker cloned by another user has no token until the new owner enters one
+	if err != nil || conf == nil || conf.Token == "" || link == "" {
+		return nil, "", err
+	}
+	if conf.Token, err = s.openToken(conf.Token); err != nil {
+		return nil, "", err
+	}
+	client, err := s.newClient(*conf)
+	if err != nil {
+		return nil, "", err
+	}
+	key, ok := client.ParseKey(link)
+	if !ok {
+		return nil, "", nil
+	}
+	return client, key, nil
+}
+
+func (s TrackerService) sealToken(token string) (string, error) {
+	if s.key == nil {
+		return "", ErrNoTrackerSecret
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return sealedPrefix + base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(token), nil)), nil
+}
+
+func (s TrackerService) openToken(stored string) (string, error) {
+	if !strings.HasPrefix(stored, sealedPrefix) {
+		return stored, nil
+	}
+	if s.key == nil {
+		return "", ErrNoTrackerSecret
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil {
+		return "", errors.Wrap(err, "unable to decode tracker token")
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("tracker token is too short")
+	}
+	token, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to decrypt tracker token")
+	}
+	return string(token), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
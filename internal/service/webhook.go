@@ -0,0 +1,65 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gotestbot/internal/dao"
+	"gotestbot/internal/service/model"
+	"gotestbot/internal/webhook"
+	"time"
+)
+
+// WebhookService manages webhooks of rooms. Deliveries are sent by the webhook dispatcher listening to the event bus.
+type WebhookService struct {
+	r *dao.Repository
+}
+
+func NewWebhookService(repository *dao.Repository) *WebhookService {
+	return &WebhookService{r: repository}
+}
+
+func (s WebhookService) GetWebhooks(roomId string) ([]model.Webhook, error) {
+	return s.r.GetWebhooksByRoomId(roomId)
+}
+
+// AddWebhook registers the url for events of the room with a new random signing secret.
+// Only https urls resolving to public addresses are accepted.
+func (s WebhookService) AddWebhook(roomId, link string) (model.Webhook, error) {
+	id, err := uuid.Parse(roomId)
+	if err != nil {
+		return model.Webhook{}, errors.Wrapf(err, "invalid roomId %v", roomId)
+	}
+	if err = webhook.ValidateUrl(link); err != nil {
+		return model.Webhook{}, errors.Wrapf(err, "invalid webhook url %q", link)
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return model.Webhook{}, err
+	}
+	hook := model.Webhook{
+		Id:          uuid.New(),
+		RoomId:      uuid.NullUUID{UUID: id, Valid: true},
+		Url:         link,
+		Secret:      hex.EncodeToString(secret),
+		CreatedDate: time.Now(),
+	}
+	if err = s.r.SaveWebhook(hook); err != nil {
+		return model.Webhook{}, err
+	}
+	return hook, nil
+}
+
+// DeleteWebhook removes the webhook if it belongs to the room.
+func (s WebhookService) DeleteWebhook(roomId, webhookId string) error {
+	hook, err := s.r.GetWebhookById(webhookId)
+	if err != nil {
+		return err
+	}
+	if hook.RoomId.UUID.String() != roomId {
+		return errors.Errorf("webhook %v does not belong to room %v", webhookId, roomId)
+	}
+	return s.r.DeleteWebhook(webhookId)
+}
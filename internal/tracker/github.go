@@ -0,0 +1,103 @@
+package tracker
+
+import (
+	"fmt"
+	"gotestbot/internal/service/model"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const defaultGitHubApi = "https://api.github.com"
+
+var githubIssueRe = regexp.MustCompile(`^https://github\.com/([\w.-]+)/([\w.-]+)/issues/(\d+)`)
+
+// GitHubClient uses GitHub REST API. Issues have no story points, so the grade is written as a label
+// made of the configured prefix and the points, e.g. "points: 5". Labels with the same prefix are replaced.
+type GitHubClient struct {
+	conf   model.TrackerConfig
+	client *http.Client
+}
+
+func NewGitHubClient(conf model.TrackerConfig, client *http.Client) *GitHubClient {
+	conf.BaseUrl = strings.TrimRight(conf.BaseUrl, "/")
+	if conf.BaseUrl == "" {
+		conf.BaseUrl = defaultGitHubApi
+	}
+	return &GitHubClient{conf: conf, client: client}
+}
+
+// ParseKey turns https://github.com/owner/repo/issues/42 into owner/repo#42.
+func (c *GitHubClient) ParseKey(url string) (string, bool) {
+	match := githubIssueRe.FindStringSubmatch(url)
+	if match == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%s#%s", match[1], match[2], match[3]), true
+}
+
+func (c *GitHubClient) GetIssue(key string) (Issue, error) {
+	req, err := c.newRequest(http.MethodGet, c.issuePath(key))
+	if err != nil {
+		return Issue{}, err
+	}
+	var issue struct {
+		Title   string `json:"title"`
+		HtmlUrl string `json:"html_url"`
+	}
+	if err = doJson(c.client, req, nil, &issue); err != nil {
+		return Issue{}, err
+	}
+	return Issue{Key: key, Title: issue.Title, Url: issue.HtmlUrl}, nil
+}
+
+func (c *GitHubClient) SetPoints(key string, points int32) error {
+	req, err := c.newRequest(http.MethodGet, c.issuePath(key)+"/labels")
+	if err != nil {
+		return err
+	}
+	var labels []struct {
+		Name string `json:"name"`
+	}
+	if err = doJson(c.client, req, nil, &labels); err != nil {
+		return err
+	}
+
+	label := fmt.Sprintf("%s%d", c.conf.PointsField, points)
+	for _, l := range labels {
+		if l.Name == label {
+			return nil
+		}
+		if !strings.HasPrefix(l.Name, c.conf.PointsField) {
+			continue
+		}
+		req, err = c.newRequest(http.MethodDelete, c.issuePath(key)+"/labels/"+url.PathEscape(l.Name))
+		if err != nil {
+			return err
+		}
+		if err = doJson(c.client, req, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	req, err = c.newRequest(http.MethodPost, c.issuePath(key)+"/labels")
+	if err != nil {
+		return err
+	}
+	return doJson(c.client, req, map[string][]string{"labels": {label}}, nil)
+}
+
+// issuePath turns owner/repo#42 into /repos/owner/repo/issues/42.
+func (c *GitHubClient) issuePath(key string) string {
+	return "/repos/" + strings.Replace(key, "#", "/issues/", 1)
+}
+
+func (c *GitHubClient) newRequest(method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.conf.BaseUrl+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "token "+c.conf.Token)
+	return req, nil
+}
@@ -0,0 +1,75 @@
+package tracker
+
+import (
+	"encoding/json"
+	"gotestbot/internal/service/model"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGitHubClient(t *testing.T) {
+	var deleted []string
+	var added []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/app/issues/42":
+			_, _ = w.Write([]byte(`{"title": "Fix crash", "html_url": "https://github.com/acme/app/issues/42"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/app/issues/42/labels":
+			_, _ = w.Write([]byte(`[{"name": "bug"}, {"name": "points: 3"}]`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/repos/acme/app/issues/42/labels/points: 3":
+			deleted = append(deleted, "points: 3")
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/app/issues/42/labels":
+			var body struct {
+				Labels []string `json:"labels"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			added = append(added, body.Labels...)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewGitHubClient(model.TrackerConfig{
+		Kind:        model.TrackerGitHub,
+		BaseUrl:     srv.URL,
+		Token:       "secret",
+		PointsField: "points: ",
+	}, srv.Client())
+
+	key, ok := client.ParseKey("https://github.com/acme/app/issues/42")
+	if !ok || key != "acme/app#42" {
+		t.Fatalf("ParseKey = %q, %v, want acme/app#42", key, ok)
+	}
+	if _, ok = client.ParseKey("https://github.com/acme/app/pull/42"); ok {
+		t.Errorf("ParseKey accepted a pull request")
+	}
+
+	issue, err := client.GetIssue(key)
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	want := Issue{Key: "acme/app#42", Title: "Fix crash", Url: "https://github.com/acme/app/issues/42"}
+	if issue != want {
+		t.Errorf("GetIssue = %+v, want %+v", issue, want)
+	}
+
+	if err = client.SetPoints(key, 5); err != nil {
+		t.Fatalf("SetPoints: %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"points: 3"}) {
+		t.Errorf("deleted labels = %v, want [points: 3]", deleted)
+	}
+	if !reflect.DeepEqual(added, []string{"points: 5"}) {
+		t.Errorf("added labels = %v, want [points: 5]", added)
+	}
+}
@@ -0,0 +1,79 @@
+package tracker
+
+import (
+	"fmt"
+	"gotestbot/internal/service/model"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var jiraKeyRe = regexp.MustCompile(`/browse/([A-Z][A-Z0-9_]+-\d+)`)
+
+// JiraClient uses Jira REST API v2. With a user name it authenticates with basic auth (Jira Cloud api token),
+// otherwise with a bearer personal access token (Jira Server).
+type JiraClient struct {
+	conf   model.TrackerConfig
+	client *http.Client
+}
+
+func NewJiraClient(conf model.TrackerConfig, client *http.Client) *JiraClient {
+	conf.BaseUrl = strings.TrimRight(conf.BaseUrl, "/")
+	return &JiraClient{conf: conf, client: client}
+}
+
+func (c *JiraClient) ParseKey(url string) (string, bool) {
+	if !strings.HasPrefix(url, c.conf.BaseUrl+"/") {
+		return "", false
+	}
+	match := jiraKeyRe.FindStringSubmatch(url)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+func (c *JiraClient) GetIssue(key string) (Issue, error) {
+	req, err := c.newRequest(http.MethodGet, "/rest/api/2/issue/"+key+"?fields=summary")
+	if err != nil {
+		return Issue{}, err
+	}
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary string `json:"summary"`
+		} `json:"fields"`
+	}
+	if err = doJson(c.client, req, nil, &issue); err != nil {
+		return Issue{}, err
+	}
+	return Issue{
+		Key:   issue.Key,
+		Title: issue.Fields.Summary,
+		Url:   fmt.Sprintf("%s/browse/%s", c.conf.BaseUrl, issue.Key),
+	}, nil
+}
+
+func (c *JiraClient) SetPoints(key string, points int32) error {
+	req, err := c.newRequest(http.MethodPut, "/rest/api/2/issue/"+key)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"fields": map[string]interface{}{c.conf.PointsField: points},
+	}
+	return doJson(c.client, req, body, nil)
+}
+
+func (c *JiraClient) newRequest(method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.conf.BaseUrl+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.conf.UserName != "" {
+		req.SetBasicAuth(c.conf.UserName, c.conf.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.conf.Token)
+	}
+	return req, nil
+}
@@ -0,0 +1,69 @@
+package tracker
+
+import (
+	"encoding/json"
+	"gotestbot/internal/service/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJiraClient(t *testing.T) {
+	var gotPoints map[string]map[string]float64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "bob" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/PRJ-7":
+			_, _ = w.Write([]byte(`{"key": "PRJ-7", "fields": {"summary": "Login page"}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/rest/api/2/issue/PRJ-7":
+			if err := json.NewDecoder(r.Body).Decode(&gotPoints); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewJiraClient(model.TrackerConfig{
+		Kind:        model.TrackerJira,
+		BaseUrl:     srv.URL + "/",
+		UserName:    "bob",
+		Token:       "secret",
+		PointsField: "customfield_10016",
+	}, srv.Client())
+
+	key, ok := client.ParseKey(srv.URL + "/browse/PRJ-7")
+	if !ok || key != "PRJ-7" {
+		t.Fatalf("ParseKey = %q, %v, want PRJ-7", key, ok)
+	}
+	if _, ok = client.ParseKey("https://other.example.com/browse/PRJ-7"); ok {
+		t.Errorf("ParseKey accepted a link of another Jira")
+	}
+
+	issue, err := client.GetIssue(key)
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	want := Issue{Key: "PRJ-7", Title: "Login page", Url: srv.URL + "/browse/PRJ-7"}
+	if issue != want {
+		t.Errorf("GetIssue = %+v, want %+v", issue, want)
+	}
+
+	if err = client.SetPoints(key, 5); err != nil {
+		t.Fatalf("SetPoints: %v", err)
+	}
+	if gotPoints["fields"]["customfield_10016"] != 5 {
+		t.Errorf("SetPoints sent %v, want 5 in customfield_10016", gotPoints)
+	}
+
+	if _, err = client.GetIssue("PRJ-8"); err == nil {
+		t.Errorf("GetIssue of a missing issue returned no error")
+	}
+}
@@ -0,0 +1,72 @@
+package tracker
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gotestbot/internal/service/model"
+	"gotestbot/internal/webhook"
+	"io"
+	"net/http"
+	"time"
+)
+
+const requestTimeout = 10 * time.Second
+
+type Issue struct {
+	Key   string
+	Title string
+	Url   string
+}
+
+// TrackerClient talks to the issue tracker bound to a room.
+type TrackerClient interface {
+	// ParseKey recognises an issue of this tracker in the task url.
+	ParseKey(url string) (string, bool)
+	GetIssue(key string) (Issue, error)
+	// SetPoints writes the final grade to the story points field configured for the room.
+	SetPoints(key string, points int32) error
+}
+
+// NewClient creates the client for the room tracker configuration. The tracker url comes from a room owner,
+// so the client only connects to public addresses.
+func NewClient(conf model.TrackerConfig) (TrackerClient, error) {
+	httpClient := webhook.NewGuardedClient()
+	switch conf.Kind {
+	case model.TrackerJira:
+		return NewJiraClient(conf, httpClient), nil
+	case model.TrackerGitHub:
+		return NewGitHubClient(conf, httpClient), nil
+	default:
+		return nil, errors.Errorf("unknown tracker kind %q", conf.Kind)
+	}
+}
+
+// doJson sends the request with a json body, if any, and decodes a json response into out, if set.
+func doJson(client *http.Client, req *http.Request, body, out interface{}) error {
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("%v %v: %v %s", req.Method, req.URL.Path, resp.Status, msg)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,130 @@
+package webapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidInitData    = errors.New("invalid web app init data")
+	ErrExpiredInitData    = errors.New("web app init data expired")
+	ErrInvalidStreamToken = errors.New("invalid or expired stream token")
+)
+
+// User is the Telegram user who opened the Web App.
+type User struct {
+	Id        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	UserName  string `json:"username"`
+}
+
+// ValidateInitData checks the initData signature made with the bot token and returns the user it was issued to.
+// Data signed earlier than maxAge ago is rejected, zero maxAge disables the check.
+// See https://core.telegram.org/bots/webapps#validating-data-received-via-the-web-app
+func ValidateInitData(initData, botToken string, maxAge time.Duration) (User, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return User{}, errors.Wrap(ErrInvalidInitData, err.Error())
+	}
+	hash := values.Get("hash")
+	if hash == "" {
+		return User{}, ErrInvalidInitData
+	}
+	expected, err := hex.DecodeString(hash)
+	if err != nil {
+		return User{}, ErrInvalidInitData
+	}
+	if !hmac.Equal(signInitData(values, botToken), expected) {
+		return User{}, ErrInvalidInitData
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return User{}, ErrInvalidInitData
+	}
+	if maxAge > 0 && time.Since(time.Unix(authDate, 0)) > maxAge {
+		return User{}, ErrExpiredInitData
+	}
+
+	var user User
+	if err = json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.Id == 0 {
+		return User{}, ErrInvalidInitData
+	}
+	return user, nil
+}
+
+// signInitData calculates HMAC-SHA256 of the sorted "key=value" lines except hash,
+// the key is HMAC-SHA256 of the bot token with "WebAppData" as a key.
+func signInitData(values url.Values, botToken string) []byte {
+	pairs := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			pairs = append(pairs, key+"="+values.Get(key))
+		}
+	}
+	sort.Strings(pairs)
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	return mac.Sum(nil)
+}
+
+// IssueStreamToken signs a short-lived token that lets the user open the live events of one room.
+// EventSource cannot set headers, so the stream takes this token in the query instead of the init data,
+// which must not end up in access logs.
+func IssueStreamToken(userId int64, roomId, botToken string, expires time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s:%d", userId, roomId, expires.Unix())))
+	return payload + "." + hex.EncodeToString(signStreamToken(payload, botToken))
+}
+
+// ValidateStreamToken returns the user the token was issued to, if it is for the room and not expired.
+func ValidateStreamToken(token, roomId, botToken string, now time.Time) (int64, error) {
+	dot := strings.IndexByte(token, '.')
+	if dot < 0 {
+		return 0, ErrInvalidStreamToken
+	}
+	payload := token[:dot]
+	signature, err := hex.DecodeString(token[dot+1:])
+	if err != nil || !hmac.Equal(signStreamToken(payload, botToken), signature) {
+		return 0, ErrInvalidStreamToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, ErrInvalidStreamToken
+	}
+	parts := strings.Split(string(data), ":")
+	if len(parts) != 3 || parts[1] != roomId {
+		return 0, ErrInvalidStreamToken
+	}
+	userId, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidStreamToken
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.After(time.Unix(expires, 0)) {
+		return 0, ErrInvalidStreamToken
+	}
+	return userId, nil
+}
+
+// signStreamToken uses a key derived from the bot token with another label than the init data key.
+func signStreamToken(payload, botToken string) []byte {
+	secret := hmac.New(sha256.New, []byte("WebAppStream"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,55 @@
+package webapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-pkgz/lgr"
+	"gotestbot/internal/httpjson"
+	"net/http"
+	"time"
+)
+
+// keepAlive is how often a comment is sent to an idle stream, so proxies do not close it
+const keepAlive = 25 * time.Second
+
+// streamEvents sends live events of the room as Server-Sent Events until the client disconnects.
+// Events carry ids only, the board reloads the state to render them.
+func (s *Server) streamEvents(rw http.ResponseWriter, req *http.Request, roomId string) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		httpjson.WriteError(rw, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	events, cancel := s.hub.Subscribe(roomId)
+	defer cancel()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(rw, "retry: 3000\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+			_, _ = fmt.Fprint(rw, ": ping\n\n")
+		case event := <-events:
+			data, err := json.Marshal(relayEvent(event))
+			if err != nil {
+				lgr.Printf("[ERROR] unable to marshal live event %v, %v", event.Type, err)
+				continue
+			}
+			if _, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
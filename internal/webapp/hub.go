@@ -0,0 +1,137 @@
+package webapp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-pkgz/lgr"
+	"gotestbot/internal/dao"
+	"gotestbot/internal/service/model"
+	"sync"
+	"time"
+)
+
+const (
+	notifyChannel = "planning_events"
+
+	// subscriberBuffer is how many events a slow client may lag behind before events are dropped for it
+	subscriberBuffer = 16
+	relayRetry       = 5 * time.Second
+)
+
+// liveEvents are the events streamed to the boards.
+var liveEvents = map[model.EventType]bool{
+	model.EventTaskPublished: true,
+	model.EventTaskRated:     true,
+	model.EventTaskRevealed:  true,
+	model.EventTaskGraded:    true,
+}
+
+// Hub is an in-process pub/sub of room events for the boards connected to this instance.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan model.Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[string]map[chan model.Event]struct{}{}}
+}
+
+// Subscribe returns events of the room until cancel is called.
+func (h *Hub) Subscribe(roomId string) (<-chan model.Event, func()) {
+	ch := make(chan model.Event, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[roomId] == nil {
+		h.subs[roomId] = map[chan model.Event]struct{}{}
+	}
+	h.subs[roomId][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[roomId], ch)
+		if len(h.subs[roomId]) == 0 {
+			delete(h.subs, roomId)
+		}
+	}
+}
+
+// Publish passes the event to subscribers of its room. A subscriber that does not keep up misses the event,
+// boards reload the whole state on the next one anyway.
+func (h *Hub) Publish(event model.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[event.RoomId] {
+		select {
+		case ch <- event:
+		default:
+			lgr.Printf("[WARN] live subscriber of room %v is too slow, %v dropped", event.RoomId, event.Type)
+		}
+	}
+}
+
+// Relay carries events between instances with Postgres LISTEN/NOTIFY: every event is sent to the channel
+// and every instance listening on it publishes the event to its hub, the sender included.
+type Relay struct {
+	r   *dao.Repository
+	hub *Hub
+}
+
+func NewRelay(repository *dao.Repository, hub *Hub) *Relay {
+	return &Relay{r: repository, hub: hub}
+}
+
+type relayEvent struct {
+	Type   model.EventType `json:"type"`
+	RoomId string          `json:"room_id"`
+	TaskId string          `json:"task_id,omitempty"`
+	UserId int64           `json:"user_id,omitempty"`
+	Date   time.Time       `json:"date"`
+}
+
+// Handle is the event listener sending live events to the channel.
+func (r *Relay) Handle(event model.Event) {
+	if !liveEvents[event.Type] {
+		return
+	}
+	payload, err := json.Marshal(relayEvent(event))
+	if err != nil {
+		lgr.Printf("[ERROR] unable to marshal live event %v, %v", event.Type, err)
+		return
+	}
+	if err = r.r.Notify(notifyChannel, string(payload)); err != nil {
+		lgr.Printf("[ERROR] unable to notify live event %v of room %v, %v", event.Type, event.RoomId, err)
+	}
+}
+
+// Listen publishes events from the channel to the hub until stop is closed, reconnecting after failures.
+func (r *Relay) Listen(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		err := r.r.Listen(ctx, notifyChannel, r.publish)
+		if ctx.Err() != nil {
+			return
+		}
+		lgr.Printf("[WARN] live events listener stopped, reconnecting in %v, %v", relayRetry, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relayRetry):
+		}
+	}
+}
+
+func (r *Relay) publish(payload string) {
+	var event relayEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		lgr.Printf("[ERROR] unable to unmarshal live event %q, %v", payload, err)
+		return
+	}
+	r.hub.Publish(model.Event(event))
+}
@@ -0,0 +1,207 @@
+package webapp
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/google/uuid"
+	"gotestbot/internal/httpjson"
+	"gotestbot/internal/service"
+	"gotestbot/internal/service/model"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	prefix = "/webapp/"
+
+	// initDataMaxAge limits how long a Web App may stay open with the same init data
+	initDataMaxAge = 24 * time.Hour
+	// streamTokenTtl is how long a stream token may be used to open the live events, an open stream is not cut
+	streamTokenTtl = time.Minute
+)
+
+// RateListener is called after a vote from the Web App is saved, so the bot can refresh the task message
+// and reveal the task the same way as after a vote with a button.
+type RateListener func(taskId, roomId string)
+
+// Server is the backend of the Telegram Web App voting board. Requests are authenticated with the Web App
+// init data signed by Telegram: "Authorization: tma <initData>". EventSource cannot set headers,
+// so the live events take a short-lived token from POST stream-token in the token query parameter.
+type Server struct {
+	roomService *service.RoomService
+	taskService *service.TaskService
+	rateService *service.RateService
+	access      *service.AccessPolicy
+	botToken    string
+	onRate      RateListener
+	hub         *Hub
+}
+
+func NewServer(roomService *service.RoomService, taskService *service.TaskService, rateService *service.RateService,
+	access *service.AccessPolicy, botToken string, onRate RateListener, hub *Hub) *Server {
+	return &Server{
+		roomService: roomService,
+		taskService: taskService,
+		rateService: rateService,
+		access:      access,
+		botToken:    botToken,
+		onRate:      onRate,
+		hub:         hub,
+	}
+}
+
+type handlerFunc func(user User, roomId string, req *http.Request) (interface{}, error)
+
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	// the board is served from another origin, the init data in the header is the only credential
+	rw.Header().Set("Access-Control-Allow-Origin", "*")
+	rw.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	rw.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	if req.Method == http.MethodOptions {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	path := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, prefix), "/"), "/")
+	if !strings.HasPrefix(req.URL.Path, prefix) || len(path) != 3 || path[0] != "rooms" {
+		httpjson.WriteError(rw, http.StatusNotFound, "not found")
+		return
+	}
+	var handler handlerFunc
+	stream := false
+	switch {
+	case path[2] == "state" && req.Method == http.MethodGet:
+		handler = s.getState
+	case path[2] == "rates" && req.Method == http.MethodPost:
+		handler = s.addRate
+	case path[2] == "stream-token" && req.Method == http.MethodPost:
+		handler = s.issueStreamToken
+	case path[2] == "events" && req.Method == http.MethodGet:
+		stream = true
+	case path[2] == "state" || path[2] == "rates" || path[2] == "events" || path[2] == "stream-token":
+		httpjson.WriteError(rw, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	default:
+		httpjson.WriteError(rw, http.StatusNotFound, "not found")
+		return
+	}
+
+	if _, err := uuid.Parse(path[1]); err != nil {
+		httpjson.WriteError(rw, http.StatusNotFound, "not found")
+		return
+	}
+
+	if stream {
+		userId, err := ValidateStreamToken(req.URL.Query().Get("token"), path[1], s.botToken, time.Now())
+		if err != nil {
+			httpjson.WriteError(rw, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err = s.access.Check(service.PermViewRoom, userId, path[1]); err != nil {
+			httpjson.HandleError(rw, "webapp", userId, req, err)
+			return
+		}
+		s.streamEvents(rw, req, path[1])
+		return
+	}
+
+	user, err := s.authenticate(req)
+	if err != nil {
+		httpjson.WriteError(rw, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	body, err := handler(user, path[1], req)
+	if err != nil {
+		httpjson.HandleError(rw, "webapp", user.Id, req, err)
+		return
+	}
+	httpjson.Write(rw, http.StatusOK, body)
+}
+
+func (s *Server) authenticate(req *http.Request) (User, error) {
+	header := req.Header.Get("Authorization")
+	if len(header) < 4 || !strings.EqualFold(header[:4], "tma ") {
+		return User{}, ErrInvalidInitData
+	}
+	return ValidateInitData(strings.TrimSpace(header[4:]), s.botToken, initDataMaxAge)
+}
+
+type streamTokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// issueStreamToken gives a member of the room a token for GET events?token=...
+func (s *Server) issueStreamToken(user User, roomId string, _ *http.Request) (interface{}, error) {
+	if err := s.access.Check(service.PermViewRoom, user.Id, roomId); err != nil {
+		return nil, err
+	}
+	return streamTokenResponse{
+		Token:     IssueStreamToken(user.Id, roomId, s.botToken, time.Now().Add(streamTokenTtl)),
+		ExpiresIn: int(streamTokenTtl.Seconds()),
+	}, nil
+}
+
+// getState returns the board of the current task, or of the task given by the taskId query parameter.
+func (s *Server) getState(user User, roomId string, req *http.Request) (interface{}, error) {
+	if err := s.access.Check(service.PermViewRoom, user.Id, roomId); err != nil {
+		return nil, err
+	}
+	return s.buildState(user, roomId, req.URL.Query().Get("taskId"))
+}
+
+type rateRequest struct {
+	TaskId string `json:"task_id"`
+	Value  string `json:"value"`
+}
+
+// addRate votes for the task with a card label, the same way as the vote buttons do, and returns the new state.
+func (s *Server) addRate(user User, roomId string, req *http.Request) (interface{}, error) {
+	if err := s.access.Check(service.PermVote, user.Id, roomId); err != nil {
+		return nil, err
+	}
+	var body rateRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, httpjson.InvalidRequest("malformed json body")
+	}
+	if _, err := uuid.Parse(body.TaskId); err != nil {
+		return nil, httpjson.InvalidRequest("task_id is required")
+	}
+
+	room, err := s.roomService.GetRoomById(roomId)
+	if err != nil {
+		return nil, err
+	}
+	task, err := s.taskService.GetTaskById(body.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if task.RoomId != room.Id {
+		return nil, sql.ErrNoRows
+	}
+	if task.Finished || task.PublishedDate == nil {
+		return nil, httpjson.InvalidRequest("voting on the task is closed")
+	}
+	card, ok := room.Scale.Card(body.Value)
+	if !ok || task.BatchId.Valid && card.Kind == model.RateKindCoffee {
+		return nil, httpjson.InvalidRequest("no such card on the room scale")
+	}
+
+	rate := model.Rate{
+		Id:          uuid.New(),
+		UserId:      user.Id,
+		TaskId:      task.Id,
+		Sum:         card.Points,
+		Kind:        card.Kind,
+		CreatedDate: time.Now(),
+	}
+	if err = s.rateService.UpsertRate(rate); err != nil {
+		return nil, err
+	}
+	if s.onRate != nil {
+		s.onRate(task.Id.String(), roomId)
+	}
+	return s.buildState(user, roomId, task.Id.String())
+}
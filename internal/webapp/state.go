@@ -0,0 +1,179 @@
+package webapp
+
+import (
+	"database/sql"
+	"github.com/google/uuid"
+	"gotestbot/internal/service/model"
+	"time"
+)
+
+type stateResponse struct {
+	Room        roomState     `json:"room"`
+	Cards       []string      `json:"cards"`
+	Abstentions []string      `json:"abstentions"`
+	Task        *taskState    `json:"task"`
+	Members     []memberState `json:"members"`
+	MyVote      *string       `json:"my_vote"`
+	Results     *resultsState `json:"results,omitempty"`
+}
+
+type roomState struct {
+	Id        string           `json:"id"`
+	Name      string           `json:"name"`
+	Status    model.RoomStatus `json:"status"`
+	Mode      model.RoomMode   `json:"mode"`
+	Anonymous bool             `json:"anonymous"`
+}
+
+type taskState struct {
+	Id       string     `json:"id"`
+	Name     string     `json:"name"`
+	Url      string     `json:"url"`
+	Round    int        `json:"round"`
+	Finished bool       `json:"finished"`
+	Grade    *string    `json:"grade"`
+	Deadline *time.Time `json:"deadline"`
+}
+
+// memberState tells who voted. The vote itself is shown after the reveal in rooms that are not anonymous.
+type memberState struct {
+	UserId   int64            `json:"user_id"`
+	Name     string           `json:"name"`
+	UserName string           `json:"user_name"`
+	Role     model.MemberRole `json:"role"`
+	Voted    bool             `json:"voted"`
+	Vote     *string          `json:"vote,omitempty"`
+}
+
+type resultsState struct {
+	Votes       int             `json:"votes"`
+	Abstentions int             `json:"abstentions"`
+	Median      string          `json:"median"`
+	Mode        string          `json:"mode"`
+	Min         string          `json:"min"`
+	Max         string          `json:"max"`
+	Consensus   model.Consensus `json:"consensus"`
+	Histogram   []histogramBar  `json:"histogram"`
+}
+
+type histogramBar struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+func (s *Server) buildState(user User, roomId, taskId string) (stateResponse, error) {
+	room, err := s.roomService.GetRoomById(roomId)
+	if err != nil {
+		return stateResponse{}, err
+	}
+	members, err := s.roomService.GetMembersByRoomId(roomId)
+	if err != nil {
+		return stateResponse{}, err
+	}
+
+	state := stateResponse{
+		Room: roomState{
+			Id:        room.Id.String(),
+			Name:      room.Name,
+			Status:    room.Status,
+			Mode:      room.Mode,
+			Anonymous: room.Anonymous,
+		},
+		Members: make([]memberState, 0, len(members)),
+	}
+	for _, card := range room.Scale.Cards() {
+		state.Cards = append(state.Cards, card.Label)
+	}
+	for _, card := range model.Abstentions {
+		state.Abstentions = append(state.Abstentions, card.Label)
+	}
+
+	task, err := s.findTask(room, taskId)
+	if err != nil {
+		return stateResponse{}, err
+	}
+	var rates []model.Rate
+	if task != nil {
+		if rates, err = s.rateService.GetRatesByTaskId(task.Id.String()); err != nil {
+			return stateResponse{}, err
+		}
+		state.Task = &taskState{
+			Id:       task.Id.String(),
+			Name:     task.Name,
+			Url:      task.Url,
+			Round:    task.Round,
+			Finished: task.Finished,
+		}
+		if task.Grade > 0 {
+			grade := room.Scale.Label(task.Grade)
+			state.Task.Grade = &grade
+		}
+		if room.HasTimer() && !task.Finished && !task.BatchId.Valid {
+			deadline := room.TaskDeadline(*task)
+			state.Task.Deadline = &deadline
+		}
+	}
+
+	userIdToRate := map[int64]model.Rate{}
+	for _, rate := range rates {
+		userIdToRate[rate.UserId] = rate
+	}
+	if rate, ok := userIdToRate[user.Id]; ok {
+		vote := room.Scale.RateLabel(rate)
+		state.MyVote = &vote
+	}
+
+	revealed := task != nil && task.Finished
+	for _, member := range members {
+		m := memberState{UserId: member.UserId, Name: member.DisplayName, UserName: member.UserName, Role: member.Role}
+		if rate, ok := userIdToRate[member.UserId]; ok {
+			m.Voted = true
+			if revealed && !room.Anonymous {
+				vote := room.Scale.RateLabel(rate)
+				m.Vote = &vote
+			}
+		}
+		state.Members = append(state.Members, m)
+	}
+
+	if revealed {
+		rounds, err := s.rateService.GetRoundStats(task.Id.String())
+		if err != nil {
+			return stateResponse{}, err
+		}
+		if len(rounds) > 0 {
+			current := rounds[len(rounds)-1]
+			state.Results = &resultsState{
+				Votes:       current.Votes,
+				Abstentions: current.Abstentions,
+				Median:      room.Scale.Label(current.Median),
+				Mode:        room.Scale.Label(current.Mode),
+				Min:         room.Scale.Label(current.Min),
+				Max:         room.Scale.Label(current.Max),
+				Consensus:   current.Consensus,
+			}
+			for _, bar := range current.Histogram {
+				state.Results.Histogram = append(state.Results.Histogram, histogramBar{Label: bar.Label, Count: bar.Count})
+			}
+		}
+	}
+	return state, nil
+}
+
+// findTask returns the requested task of the room, or the current one when no task is requested.
+func (s *Server) findTask(room model.Room, taskId string) (*model.Task, error) {
+	if taskId == "" {
+		return s.taskService.GetCurrentTask(room.Id.String())
+	}
+	if _, err := uuid.Parse(taskId); err != nil {
+		return nil, sql.ErrNoRows
+	}
+	task, err := s.taskService.GetTaskById(taskId)
+	if err != nil {
+		return nil, err
+	}
+	if task.RoomId != room.Id || task.PublishedDate == nil {
+		return nil, sql.ErrNoRows
+	}
+	return &task, nil
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+)
+
+var ErrForbiddenUrl = errors.New("url must be https and resolve to a public address")
+
+// carrierNat is the shared address space of RFC 6598, it is not routable from the internet either.
+var carrierNat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// ValidateUrl checks that a url given by a room owner, a webhook or a tracker, points to a public https endpoint,
+// so room owners cannot make the bot call services of its own network. NewGuardedClient repeats the check on every connection.
+func ValidateUrl(link string) error {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrForbiddenUrl
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return errors.Wrapf(err, "unable to resolve webhook host %v", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return ErrForbiddenUrl
+		}
+	}
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || carrierNat.Contains(ip))
+}
+
+// NewGuardedClient refuses to connect to non-public addresses, which also covers redirects
+// and hosts that resolve differently than at registration.
+func NewGuardedClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrForbiddenUrl
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return ErrForbiddenUrl
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,267 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/go-pkgz/lgr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gotestbot/internal/dao"
+	"gotestbot/internal/scheduler"
+	"gotestbot/internal/service/model"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	JobWebhookDelivery = "WEBHOOK_DELIVERY"
+
+	maxAttempts    = 6
+	requestTimeout = 10 * time.Second
+
+	HeaderEvent     = "X-Planning-Event"
+	HeaderDelivery  = "X-Planning-Delivery"
+	HeaderSignature = "X-Planning-Signature"
+)
+
+// webhookEvents are the events delivered to webhooks. Votes are too frequent and stay with live clients.
+var webhookEvents = map[model.EventType]bool{
+	model.EventTaskPublished: true,
+	model.EventTaskRevealed:  true,
+	model.EventTaskGraded:    true,
+	model.EventRoomFinished:  true,
+	model.EventMemberJoined:  true,
+}
+
+// Dispatcher turns events into signed HTTP deliveries. Each delivery is logged and sent by a scheduler job,
+// so failed deliveries are retried with the scheduler backoff.
+type Dispatcher struct {
+	r         *dao.Repository
+	scheduler *scheduler.Scheduler
+	// client delivers room webhooks and only connects to public addresses
+	client *http.Client
+	// globalClient delivers deployment-wide webhooks, they come from the config and may point to the internal network
+	globalClient *http.Client
+
+	// global are deployment-wide webhooks receiving events of every room
+	global []model.Webhook
+}
+
+func NewDispatcher(repository *dao.Repository, scheduler *scheduler.Scheduler, global []model.Webhook) *Dispatcher {
+	d := &Dispatcher{
+		r:            repository,
+		scheduler:    scheduler,
+		client:       NewGuardedClient(),
+		globalClient: &http.Client{Timeout: requestTimeout},
+		global:       global,
+	}
+	scheduler.Register(JobWebhookDelivery, d.deliver)
+	return d
+}
+
+type payload struct {
+	Id   string          `json:"id"`
+	Type model.EventType `json:"type"`
+	Date time.Time       `json:"date"`
+	Room *roomPayload    `json:"room,omitempty"`
+	Task *taskPayload    `json:"task,omitempty"`
+	User *userPayload    `json:"user,omitempty"`
+}
+
+type roomPayload struct {
+	Id     string           `json:"id"`
+	Name   string           `json:"name"`
+	Status model.RoomStatus `json:"status"`
+}
+
+type taskPayload struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	Grade    int32  `json:"grade"`
+	Round    int    `json:"round"`
+	Finished bool   `json:"finished"`
+}
+
+type userPayload struct {
+	Id       int64  `json:"id"`
+	UserName string `json:"user_name"`
+	Name     string `json:"name"`
+}
+
+// Handle is the event listener. It logs a delivery per webhook of the room and per deployment-wide webhook.
+func (d *Dispatcher) Handle(event model.Event) {
+	if !webhookEvents[event.Type] {
+		return
+	}
+	hooks, err := d.r.GetWebhooksByRoomId(event.RoomId)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to get webhooks of room: %v, %v", event.RoomId, err)
+	}
+	hooks = append(hooks, d.global...)
+	if len(hooks) == 0 {
+		return
+	}
+
+	body, err := d.buildPayload(event)
+	if err != nil {
+		lgr.Printf("[ERROR] unable to build webhook payload for %v, %v", event.Type, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		delivery := model.WebhookDelivery{
+			Id:          uuid.New(),
+			Url:         hook.Url,
+			Event:       event.Type,
+			Payload:     body,
+			Status:      model.DeliveryPending,
+			CreatedDate: time.Now(),
+		}
+		if hook.RoomId.Valid {
+			delivery.WebhookId = uuid.NullUUID{UUID: hook.Id, Valid: true}
+		}
+		if err = d.r.SaveWebhookDelivery(delivery); err != nil {
+			lgr.Printf("[ERROR] %v", err)
+			continue
+		}
+		err = d.scheduler.ScheduleWithAttempts(JobWebhookDelivery, time.Now(), model.JobPayload{
+			"deliveryId": delivery.Id.String(),
+		}, maxAttempts)
+		if err != nil {
+			lgr.Printf("[ERROR] unable to schedule webhook delivery %v, %v", delivery.Id, err)
+		}
+	}
+}
+
+func (d *Dispatcher) buildPayload(event model.Event) (string, error) {
+	p := payload{Id: uuid.NewString(), Type: event.Type, Date: event.Date}
+
+	if event.RoomId != "" {
+		room, err := d.r.GetRoomById(event.RoomId)
+		if err != nil {
+			return "", err
+		}
+		p.Room = &roomPayload{Id: room.Id.String(), Name: room.Name, Status: room.Status}
+	}
+	if event.TaskId != "" {
+		task, err := d.r.GetTaskById(event.TaskId)
+		if err != nil {
+			return "", err
+		}
+		p.Task = &taskPayload{
+			Id:       task.Id.String(),
+			Name:     task.Name,
+			Url:      task.Url,
+			Grade:    task.Grade,
+			Round:    task.Round,
+			Finished: task.Finished,
+		}
+	}
+	if event.UserId != 0 {
+		user, err := d.r.GetUser(event.UserId)
+		if err != nil {
+			return "", err
+		}
+		p.User = &userPayload{Id: user.UserId, UserName: user.UserName, Name: user.DisplayName}
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// deliver sends one delivery. An error makes the scheduler retry the job, the last failed attempt marks it FAILED.
+func (d *Dispatcher) deliver(jobPayload model.JobPayload) error {
+	delivery, err := d.r.GetWebhookDeliveryById(jobPayload["deliveryId"])
+	if err != nil {
+		return err
+	}
+	if delivery.Status != model.DeliveryPending {
+		return nil
+	}
+
+	secret, err := d.secret(delivery)
+	if err != nil {
+		return err
+	}
+
+	code, err := d.post(delivery, secret)
+	status, deliveredDate, lastError := model.DeliveryDelivered, timeNow(), ""
+	if err != nil {
+		status, deliveredDate, lastError = model.DeliveryPending, nil, err.Error()
+		if delivery.Attempts+1 >= maxAttempts {
+			status = model.DeliveryFailed
+		}
+	}
+	if dbErr := d.r.SetWebhookDeliveryAttempt(delivery.Id.String(), status, code, lastError, deliveredDate); dbErr != nil {
+		lgr.Printf("[ERROR] unable to log webhook delivery attempt %v, %v", delivery.Id, dbErr)
+	}
+	return err
+}
+
+// secret finds the signing key. Room webhooks keep it in the table, deployment-wide ones in the config.
+func (d *Dispatcher) secret(delivery model.WebhookDelivery) (string, error) {
+	if delivery.WebhookId.Valid {
+		hook, err := d.r.GetWebhookById(delivery.WebhookId.UUID.String())
+		if err != nil {
+			return "", err
+		}
+		return hook.Secret, nil
+	}
+	for _, hook := range d.global {
+		if hook.Url == delivery.Url {
+			return hook.Secret, nil
+		}
+	}
+	return "", errors.Errorf("webhook %v is not configured anymore", delivery.Url)
+}
+
+func (d *Dispatcher) post(delivery model.WebhookDelivery, secret string) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.Id.String())
+	req.Header.Set(HeaderSignature, "sha256="+Sign(secret, body))
+
+	client := d.globalClient
+	if delivery.WebhookId.Valid {
+		if err = ValidateUrl(delivery.Url); err != nil {
+			return 0, err
+		}
+		client = d.client
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.Errorf("webhook responded %v", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns hex encoded HMAC-SHA256 of the body. Receivers compare it with the X-Planning-Signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func timeNow() *time.Time {
+	now := time.Now()
+	return &now
+}
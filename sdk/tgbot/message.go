@@ -11,6 +11,11 @@ type MessageBuilder struct {
 	inlineId    string
 	text        string
 	keyboard    [][]tgbotapi.InlineKeyboardButton
+	photo       *tgbotapi.FileBytes
+	document    *tgbotapi.FileBytes
+
+	// webApps holds Web App urls by button position, see AddButtonWebApp
+	webApps map[[2]int]string
 }
 
 func (b *MessageBuilder) EditMessageTextAndMarkup(chatId int64, messageId int) *MessageBuilder {
@@ -39,6 +44,20 @@ func (b *MessageBuilder) Text(text string) *MessageBuilder {
 	return b
 }
 
+// Photo makes the builder send a new photo message, the text becomes its caption.
+func (b *MessageBuilder) Photo(name string, data []byte) *MessageBuilder {
+	b.photo = &tgbotapi.FileBytes{Name: name, Bytes: data}
+	b.editMessage = false
+	return b
+}
+
+// Document makes the builder send a new document message, the text becomes its caption.
+func (b *MessageBuilder) Document(name string, data []byte) *MessageBuilder {
+	b.document = &tgbotapi.FileBytes{Name: name, Bytes: data}
+	b.editMessage = false
+	return b
+}
+
 func (b *MessageBuilder) ChatId(chatId int64) *MessageBuilder {
 	b.chatId = chatId
 	return b
@@ -81,6 +100,18 @@ func (b *MessageBuilder) AddButtonUrl(text, url string) *MessageBuilder {
 	return b
 }
 
+// AddButtonWebApp adds a button opening a Telegram Web App. Telegram accepts such buttons in private chats only,
+// and they are sent with new messages only since edits cannot carry them in tgbotapi.
+func (b *MessageBuilder) AddButtonWebApp(text, url string) *MessageBuilder {
+	row := len(b.keyboard) - 1
+	if b.webApps == nil {
+		b.webApps = map[[2]int]string{}
+	}
+	b.webApps[[2]int{row, len(b.keyboard[row])}] = url
+	b.keyboard[row] = append(b.keyboard[row], tgbotapi.InlineKeyboardButton{Text: text})
+	return b
+}
+
 func (b *MessageBuilder) AddButtonSwitch(text, sw string) *MessageBuilder {
 	b.keyboard[len(b.keyboard)-1] = append(b.keyboard[len(b.keyboard)-1],
 		tgbotapi.NewInlineKeyboardButtonSwitch(text, sw),
@@ -89,6 +120,24 @@ func (b *MessageBuilder) AddButtonSwitch(text, sw string) *MessageBuilder {
 }
 
 func (b *MessageBuilder) Build() tgbotapi.Chattable {
+	if b.document != nil {
+		msg := tgbotapi.NewDocument(b.chatId, *b.document)
+		msg.Caption = b.text
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		if keyboard := b.getKeyboard(); len(keyboard) > 0 {
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+		}
+		return msg
+	}
+	if b.photo != nil {
+		msg := tgbotapi.NewPhoto(b.chatId, *b.photo)
+		msg.Caption = b.text
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		if keyboard := b.getKeyboard(); len(keyboard) > 0 {
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+		}
+		return msg
+	}
 	if b.editMessage {
 		kb := b.getKeyboard()
 		var msg tgbotapi.Chattable
@@ -109,7 +158,9 @@ func (b *MessageBuilder) Build() tgbotapi.Chattable {
 	} else {
 		msg := tgbotapi.NewMessage(b.chatId, b.text)
 		keyboard := b.getKeyboard()
-		if len(keyboard) > 0 {
+		if len(b.webApps) > 0 {
+			msg.ReplyMarkup = b.getWebAppKeyboard()
+		} else if len(keyboard) > 0 {
 			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
 		}
 		msg.ParseMode = tgbotapi.ModeMarkdown
@@ -128,6 +179,39 @@ func (b *MessageBuilder) getKeyboard() [][]tgbotapi.InlineKeyboardButton {
 	return keyboard
 }
 
+// webAppButton adds the web_app field missing in tgbotapi.InlineKeyboardButton.
+type webAppButton struct {
+	tgbotapi.InlineKeyboardButton
+	WebApp *webAppInfo `json:"web_app,omitempty"`
+}
+
+type webAppInfo struct {
+	Url string `json:"url"`
+}
+
+type webAppKeyboard struct {
+	InlineKeyboard [][]webAppButton `json:"inline_keyboard"`
+}
+
+func (b *MessageBuilder) getWebAppKeyboard() webAppKeyboard {
+	var keyboard webAppKeyboard
+	for i, buttons := range b.keyboard {
+		if len(buttons) == 0 {
+			continue
+		}
+		row := make([]webAppButton, 0, len(buttons))
+		for j, button := range buttons {
+			btn := webAppButton{InlineKeyboardButton: button}
+			if url, ok := b.webApps[[2]int{i, j}]; ok {
+				btn.WebApp = &webAppInfo{Url: url}
+			}
+			row = append(row, btn)
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+	}
+	return keyboard
+}
+
 type inlineMessageBuilder struct {
 	inlineQueryId string
 	articles      []*tgbotapi.InlineQueryResultArticle
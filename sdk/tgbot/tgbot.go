@@ -4,6 +4,7 @@ import (
 	"github.com/go-pkgz/lgr"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/pkg/errors"
+	"io"
 	"net/http"
 )
 
@@ -15,6 +16,10 @@ type ChatProvider interface {
 	SaveUser(user User) error
 }
 
+// AllowedUpdates are the update types the bot receives. chat_member is not sent by default and is delivered
+// only to chat administrators, so it has to be listed explicitly, webhooks need the same list in setWebhook.
+var AllowedUpdates = []string{"message", "edited_message", "callback_query", "inline_query", "my_chat_member", "chat_member"}
+
 type Bot struct {
 	*tgbotapi.BotAPI
 	handler  func(update *Update)
@@ -38,6 +43,7 @@ func (b *Bot) StartLongPolling(handler func(update *Update)) error {
 	b.handler = handler
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
+	u.AllowedUpdates = AllowedUpdates
 
 	for update := range b.GetUpdatesChan(u) {
 		wrappedUpdate, err := b.WrapUpdate(update)
@@ -50,6 +56,19 @@ func (b *Bot) StartLongPolling(handler func(update *Update)) error {
 	return nil
 }
 
+// SetWebhook points Telegram to the webhook url with AllowedUpdates, which are otherwise applied only in long polling.
+func (b *Bot) SetWebhook(link string) error {
+	wh, err := tgbotapi.NewWebhook(link)
+	if err != nil {
+		return errors.Wrapf(err, "invalid webhook url %v", link)
+	}
+	wh.AllowedUpdates = AllowedUpdates
+	if _, err = b.Request(wh); err != nil {
+		return errors.Wrap(err, "unable to set webhook")
+	}
+	return nil
+}
+
 func (b *Bot) WrapUpdate(update tgbotapi.Update) (*Update, error) {
 	user, err := b.SaveUser(&update)
 	if err != nil {
@@ -67,6 +86,39 @@ func (b *Bot) WrapRequest(req *http.Request) (*Update, error) {
 	return b.WrapUpdate(*update)
 }
 
+// DownloadFile fetches a file sent to the bot through the Bot API file endpoint. Files larger than maxSize are rejected.
+func (b *Bot) DownloadFile(fileId string, maxSize int64) ([]byte, error) {
+	file, err := b.GetFile(tgbotapi.FileConfig{FileID: fileId})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get file, fileId: %v", fileId)
+	}
+	if int64(file.FileSize) > maxSize {
+		return nil, errors.Errorf("file is too large, fileId: %v, size: %d", fileId, file.FileSize)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, file.Link(b.Token), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download file, fileId: %v", fileId)
+	}
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download file, fileId: %v", fileId)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to download file, fileId: %v, status: %v", fileId, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read file, fileId: %v", fileId)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.Errorf("file is too large, fileId: %v", fileId)
+	}
+	return data, nil
+}
+
 func (b *Bot) SaveUser(update *tgbotapi.Update) (User, error) {
 	tgUser, err := getFrom(update)
 	if err != nil {
@@ -93,7 +145,10 @@ func getFrom(update *tgbotapi.Update) (tgbotapi.User, error) {
 		user = update.InlineQuery.From
 	} else if update.MyChatMember != nil {
 		user = &update.MyChatMember.From
-	} else {
+	} else if update.ChatMember != nil {
+		user = &update.ChatMember.From
+	}
+	if user == nil {
 		return tgbotapi.User{}, errors.Errorf("Not define user, update - %v", update)
 	}
 	return *user, nil
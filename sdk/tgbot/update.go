@@ -29,6 +29,12 @@ func (u *Update) GetUserId() int64 {
 	if u.InlineQuery != nil {
 		return u.InlineQuery.From.ID
 	}
+	if u.ChatMember != nil {
+		return u.ChatMember.From.ID
+	}
+	if u.MyChatMember != nil {
+		return u.MyChatMember.From.ID
+	}
 	return 0
 }
 
@@ -39,6 +45,12 @@ func (u *Update) GetChatId() int64 {
 	if u.CallbackQuery != nil {
 		return u.CallbackQuery.Message.Chat.ID
 	}
+	if u.ChatMember != nil {
+		return u.ChatMember.Chat.ID
+	}
+	if u.MyChatMember != nil {
+		return u.MyChatMember.Chat.ID
+	}
 	return 0
 }
 
@@ -88,6 +100,19 @@ func (u *Update) GetText() string {
 	return u.Message.Text
 }
 
+//Document
+
+func (u *Update) HasDocument() bool {
+	return u.Message != nil && u.Message.Document != nil
+}
+
+func (u *Update) GetDocument() tgbotapi.Document {
+	if !u.HasDocument() {
+		return tgbotapi.Document{}
+	}
+	return *u.Message.Document
+}
+
 func (u *Update) GetInline() string {
 	if u.InlineQuery != nil {
 		return u.InlineQuery.Query
@@ -133,6 +158,55 @@ func (u *Update) HasAction(action Action) bool {
 	return u.IsButton() && u.GetButton().HasAction(action)
 }
 
+//Chat members
+
+// GetJoinedMembers returns users who joined the group, either from the new_chat_members service message
+// or from a chat_member update.
+func (u *Update) GetJoinedMembers() []tgbotapi.User {
+	if u.Message != nil && u.Message.NewChatMembers != nil {
+		return u.Message.NewChatMembers
+	}
+	if u.ChatMember != nil && !inChat(u.ChatMember.OldChatMember) && inChat(u.ChatMember.NewChatMember) {
+		return []tgbotapi.User{*u.ChatMember.NewChatMember.User}
+	}
+	return nil
+}
+
+// GetLeftMember returns the user who left or was removed from the group, either from the left_chat_member service message
+// or from a chat_member update.
+func (u *Update) GetLeftMember() *tgbotapi.User {
+	if u.Message != nil && u.Message.LeftChatMember != nil {
+		return u.Message.LeftChatMember
+	}
+	if u.ChatMember != nil && inChat(u.ChatMember.OldChatMember) && !inChat(u.ChatMember.NewChatMember) {
+		return u.ChatMember.NewChatMember.User
+	}
+	return nil
+}
+
+// IsBotRemoved tells whether the bot itself left or was removed from the chat.
+func (u *Update) IsBotRemoved() bool {
+	return u.MyChatMember != nil && inChat(u.MyChatMember.OldChatMember) && !inChat(u.MyChatMember.NewChatMember)
+}
+
+// GetMigrateToChatId returns the id of the supergroup the group was upgraded to, 0 for other updates.
+func (u *Update) GetMigrateToChatId() int64 {
+	if u.Message != nil {
+		return u.Message.MigrateToChatID
+	}
+	return 0
+}
+
+func inChat(member tgbotapi.ChatMember) bool {
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return member.IsMember
+	}
+	return false
+}
+
 // ChatInfo
 
 func (u *Update) HasActionOrChain(actionOrChain Action) bool {
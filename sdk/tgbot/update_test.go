@@ -0,0 +1,57 @@
+package tgbot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"testing"
+)
+
+func myChatMemberUpdate(oldMember, newMember tgbotapi.ChatMember) *Update {
+	return &Update{Update: tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: -100, Type: "group"},
+		OldChatMember: oldMember,
+		NewChatMember: newMember,
+	}}}
+}
+
+func TestIsBotRemoved(t *testing.T) {
+	tests := []struct {
+		name string
+		u    *Update
+		want bool
+	}{
+		{"kicked", myChatMemberUpdate(tgbotapi.ChatMember{Status: "member"}, tgbotapi.ChatMember{Status: "kicked"}), true},
+		{"left", myChatMemberUpdate(tgbotapi.ChatMember{Status: "administrator"}, tgbotapi.ChatMember{Status: "left"}), true},
+		{"restricted out of the chat", myChatMemberUpdate(tgbotapi.ChatMember{Status: "member"}, tgbotapi.ChatMember{Status: "restricted"}), true},
+		{"restricted in the chat", myChatMemberUpdate(tgbotapi.ChatMember{Status: "member"}, tgbotapi.ChatMember{Status: "restricted", IsMember: true}), false},
+		{"added", myChatMemberUpdate(tgbotapi.ChatMember{Status: "left"}, tgbotapi.ChatMember{Status: "member"}), false},
+		{"promoted", myChatMemberUpdate(tgbotapi.ChatMember{Status: "member"}, tgbotapi.ChatMember{Status: "administrator"}), false},
+		{"message", &Update{Update: tgbotapi.Update{Message: &tgbotapi.Message{Text: "hi"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.u.IsBotRemoved(); got != tt.want {
+				t.Errorf("IsBotRemoved() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMigrateToChatId(t *testing.T) {
+	migrated := &Update{Update: tgbotapi.Update{Message: &tgbotapi.Message{
+		Chat:            &tgbotapi.Chat{ID: -100},
+		MigrateToChatID: -1001234567890,
+	}}}
+	if got := migrated.GetMigrateToChatId(); got != -1001234567890 {
+		t.Errorf("GetMigrateToChatId() = %v, want -1001234567890", got)
+	}
+
+	message := &Update{Update: tgbotapi.Update{Message: &tgbotapi.Message{Text: "hi"}}}
+	if got := message.GetMigrateToChatId(); got != 0 {
+		t.Errorf("GetMigrateToChatId() of a plain message = %v, want 0", got)
+	}
+
+	callback := &Update{Update: tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: "x"}}}
+	if got := callback.GetMigrateToChatId(); got != 0 {
+		t.Errorf("GetMigrateToChatId() of a callback = %v, want 0", got)
+	}
+}